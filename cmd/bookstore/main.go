@@ -0,0 +1,16 @@
+package main
+
+import (
+	"Bookstore/internal/cli"
+	"fmt"
+	_ "github.com/lib/pq"
+	"os"
+)
+
+// bookstore — административный CLI: serve, migrate, user, book, token
+func main() {
+	if err := cli.Run(os.Args[1:]); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
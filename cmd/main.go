@@ -2,10 +2,21 @@ package main
 
 import (
 	"Bookstore/internal/app"
+	"Bookstore/internal/cli"
+	"fmt"
 	_ "github.com/lib/pq"
+	"os"
 )
 
 // Salam bu ver2.1
+// Без аргументов запускает сервер, с аргументами работает как CLI bookstore
 func main() {
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	app.Run()
 }
@@ -0,0 +1,21 @@
+package actor
+
+import "context"
+
+// System автор изменений, сделанных не пользователем: миграции, фоновые задачи
+const System = "system"
+
+type key struct{}
+
+// With кладёт в контекст, от чьего имени выполняется запрос (имя пользователя, "cli")
+func With(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, key{}, name)
+}
+
+// From автор из контекста или System
+func From(ctx context.Context) string {
+	if name, ok := ctx.Value(key{}).(string); ok && name != "" {
+		return name
+	}
+	return System
+}
@@ -2,63 +2,283 @@ package app
 
 import (
 	"Bookstore/internal/handler"
+	"Bookstore/internal/health"
+	"Bookstore/internal/i18n"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/migrations"
+	"Bookstore/internal/openapi"
 	"Bookstore/internal/repository"
 	"Bookstore/internal/routes"
 	"Bookstore/internal/service"
+	"Bookstore/internal/tracing"
 	config "Bookstore/pkg/database"
+	"context"
 	"database/sql"
+	"errors"
 	"go.uber.org/zap"
 	"log" // Для логирования
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
-// InitApp инициализирует все зависимости (репозитории, сервисы, обработчики)
-func InitApp(db *sql.DB, logger *zap.Logger) (*handler.AuthHandler, *handler.BookHandler) {
-	log.Println("Initializing repositories, services, and handlers...")
+// Services сервисы приложения; их же использует CLI
+type Services struct {
+	Auth           *service.AuthService
+	Books          service.BOokService
+	Stock          service.StockService
+	Locations      service.LocationService
+	Transfers      service.TransferService
+	Orders         service.OrderService
+	Reservations   service.ReservationService
+	Reorder        service.ReorderService
+	Suppliers      service.SupplierService
+	PurchaseOrders service.PurchaseOrderService
+	BookImport     service.BookImportService
+	Onix           service.OnixService
+}
 
-	// Initialize dependencies for users
-	userRepo := repository.NewUserRepository(db, logger)
-	userService := service.NewUserService(userRepo, logger)
-	userHandler := handler.NewAuthHandler(userService)
-	log.Println("User dependencies initialized successfully.")
+// InitServices инициализирует репозитории и сервисы, без HTTP слоя (используется и CLI)
+func InitServices(db *sql.DB, reg metrics.Registry) *Services {
+	repoDB := repository.NewDB(db, config.StatementTimeout())
 
-	// Initialize dependencies for books
-	bookRepo := repository.NewBookRepository(db, logger)
-	bookService := service.NewBookService(bookRepo, logger)
-	bookHandler := handler.NewBookHandler(bookService)
-	log.Println("Book dependencies initialized successfully.")
+	userRepo := repository.NewUserRepository(repoDB)
+	bookRepo := repository.NewBookRepository(repoDB)
+	stockRepo := repository.NewStockRepository(repoDB)
+	locationRepo := repository.NewLocationRepository(repoDB)
+	transferRepo := repository.NewTransferRepository(repoDB)
+	orderRepo := repository.NewOrderRepository(repoDB)
+	reservationRepo := repository.NewReservationRepository(repoDB)
+	reorderRepo := repository.NewReorderRepository(repoDB)
+	supplierRepo := repository.NewSupplierRepository(repoDB)
+	purchaseOrderRepo := repository.NewPurchaseOrderRepository(repoDB)
+	importJobRepo := repository.NewImportJobRepository(repoDB)
 
-	return userHandler, bookHandler
+	books := service.NewBookService(bookRepo, reg)
+	return &Services{
+		Auth:           service.NewUserService(userRepo, reg),
+		Books:          books,
+		Stock:          service.NewStockService(stockRepo, reg),
+		Locations:      service.NewLocationService(locationRepo),
+		Transfers:      service.NewTransferService(transferRepo),
+		Orders:         service.NewOrderService(orderRepo, reg),
+		Reservations:   service.NewReservationService(reservationRepo, reg, LoadReservationConfig().TTL),
+		Reorder:        service.NewReorderService(reorderRepo, LoadNotifier(), reg, LoadReorderConfig().SalesWindowDays),
+		Suppliers:      service.NewSupplierService(supplierRepo),
+		PurchaseOrders: service.NewPurchaseOrderService(purchaseOrderRepo, reg),
+		BookImport:     service.NewBookImportService(books, importJobRepo, reg, LoadImportConfig().SyncRows),
+		Onix:           service.NewOnixService(books, reg, LoadOnixConfig().Currency),
+	}
 }
 
-func Run() {
-	log.Println("Connecting to the database...")
+// InitApp инициализирует все зависимости (репозитории, сервисы, обработчики);
+// сервисы возвращаются и отдельно — их используют фоновые воркеры
+func InitApp(db *sql.DB, reg metrics.Registry) (*Services, routes.Handlers) {
+	services := InitServices(db, reg)
+	return services, routes.Handlers{
+		Auth:          handler.NewAuthHandler(services.Auth),
+		Book:          handler.NewBookHandler(services.Books),
+		Stock:         handler.NewStockHandler(services.Stock),
+		Location:      handler.NewLocationHandler(services.Locations),
+		Transfer:      handler.NewTransferHandler(services.Transfers),
+		Order:         handler.NewOrderHandler(services.Orders),
+		Reservation:   handler.NewReservationHandler(services.Reservations),
+		Reorder:       handler.NewReorderHandler(services.Reorder),
+		Supplier:      handler.NewSupplierHandler(services.Suppliers),
+		PurchaseOrder: handler.NewPurchaseOrderHandler(services.PurchaseOrders),
+		BookImport:    handler.NewBookImportHandler(services.BookImport, LoadImportConfig().MaxBytes),
+		Onix:          handler.NewOnixHandler(services.Onix, LoadOnixConfig().Timeout),
+		BookExport:    handler.NewBookExportHandler(services.Books, LoadExportConfig().Timeout),
+	}
+}
 
-	db, err := config.ConnectDB()
+// App держит HTTP сервер, фоновые воркеры и пул соединений и останавливает их по порядку
+type App struct {
+	cfg    ServerConfig
+	db     *sql.DB
+	logger *zap.Logger
+	server *http.Server
+	health *health.Registry
+
+	workerCtx     context.Context
+	cancelWorkers context.CancelFunc
+	workers       sync.WaitGroup
+}
+
+// New создаёт App; handler — готовый gin роутер, checks — реестр проверок /healthz и /readyz
+func New(cfg ServerConfig, db *sql.DB, logger *zap.Logger, handler http.Handler, checks *health.Registry) *App {
+	workerCtx, cancel := context.WithCancel(context.Background())
+	return &App{
+		cfg:    cfg,
+		db:     db,
+		logger: logger,
+		server: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		health:        checks,
+		workerCtx:     workerCtx,
+		cancelWorkers: cancel,
+	}
+}
+
+// Go запускает фоновый воркер; его ctx отменяется при остановке, App ждёт завершения.
+// Воркер должен вызывать hb.Beat() хотя бы раз в maxSilence, иначе /healthz провалится
+func (a *App) Go(name string, maxSilence time.Duration, worker func(ctx context.Context, hb *health.Heartbeat)) {
+	hb := health.NewHeartbeat()
+	a.health.AddLiveness("worker:"+name, hb.Check(maxSilence))
+
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		a.logger.Info("Background worker started", zap.String("worker", name))
+		worker(a.workerCtx, hb)
+		a.logger.Info("Background worker stopped", zap.String("worker", name))
+	}()
+}
+
+// Serve принимает запросы, пока ctx не отменён, затем выполняет Shutdown
+func (a *App) Serve(ctx context.Context) error {
+	// Слушаем порт синхронно: Ready ставим только когда сокет открыт, ошибку адреса возвращаем сразу
+	ln, err := net.Listen("tcp", a.cfg.Addr)
 	if err != nil {
-		log.Fatalf("Failed to connect to the database: %v", err)
+		a.health.SetState(health.StateStopping)
+		a.stopBackground()
+		return err
 	}
-	log.Println("Database connection established successfully.")
 
-	// Initialize the logger
-	logger, err := zap.NewProduction()
+	errCh := make(chan error, 1)
+	go func() {
+		a.logger.Info("Starting the server", zap.String("addr", ln.Addr().String()))
+		if err := a.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+	a.health.SetState(health.StateReady)
+
+	select {
+	case err := <-errCh:
+		a.health.SetState(health.StateStopping)
+		a.stopBackground()
+		return err
+	case <-ctx.Done():
+	}
+	return a.Shutdown()
+}
+
+// Shutdown: readiness -> пауза DrainDelay -> дренаж HTTP -> воркеры -> пул БД
+func (a *App) Shutdown() error {
+	a.logger.Info("Shutting down: readiness is now failing")
+	a.health.SetState(health.StateStopping)
+	time.Sleep(a.cfg.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var shutdownErr error
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.logger.Error("HTTP server did not drain in time", zap.Error(err))
+		shutdownErr = err
+	} else {
+		a.logger.Info("HTTP server drained")
+	}
+
+	a.stopBackground()
+	return shutdownErr
+}
+
+// stopBackground останавливает воркеры и только потом закрывает пул соединений
+func (a *App) stopBackground() {
+	a.cancelWorkers()
+	a.workers.Wait()
+
+	if err := a.db.Close(); err != nil {
+		a.logger.Error("Failed to close database pool", zap.Error(err))
+		return
+	}
+	a.logger.Info("Database pool closed")
+}
+
+func Run() {
+	cfg := LoadServerConfig()
+
+	// Базовый логгер; логгеры запросов наследуют его через middleware.RequestLogger
+	logger, err := logging.New()
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 	defer func(logger *zap.Logger) {
-		err := logger.Sync()
-		if err != nil {
+		_ = logger.Sync()
+	}(logger) // Flushes buffer, if any
+
+	// Неполный каталог переводов — падаем при старте, а не отдаём клиенту ключ вместо текста
+	if err := i18n.Check(); err != nil {
+		logger.Fatal("Translation catalog check failed", zap.Error(err))
+	}
+
+	logger.Info("Connecting to the database...")
+	db, err := config.ConnectDB()
+	if err != nil {
+		logger.Fatal("Failed to connect to the database", zap.Error(err))
+	}
+	logger.Info("Database connection established successfully")
 
+	migrator, err := migrations.New(db, logger)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if autoMigrate() {
+		logger.Info("Applying database migrations...")
+		if err := migrator.Up(context.Background()); err != nil {
+			logger.Fatal("Failed to apply migrations", zap.Error(err))
 		}
-	}(logger) // Flushes buffer, if any
+	}
+
+	tracer, err := tracing.Setup(context.Background(), tracing.LoadConfig())
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	// Отправляем оставшиеся span уже после остановки сервера и воркеров
+	defer func(tracer *tracing.Provider) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracer.Shutdown(ctx); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+	}(tracer)
+
+	reg := metrics.NewPrometheus()
+	reg.RegisterDBStats(db, config.DBName())
 
 	// Initialize dependencies
-	authHandler, bookHandler := InitApp(db, logger)
+	services, handlers := InitApp(db, reg)
+
+	// Readiness остаётся fail (StateStarting), пока сервер не начал слушать порт
+	checks := health.NewRegistry()
+	checks.AddReadiness("database", health.DBPing(db))
+	checks.AddReadiness("migrations", health.MigrationsAtHead(migrator))
+
+	r := routes.SetupRoutes(logger, checks, reg, LoadRoutesConfig(), handlers)
+	if err := openapi.Check(r.Routes(), "/v1"); err != nil {
+		logger.Warn("OpenAPI document is out of date", zap.Error(err))
+	}
 
-	log.Println("Setting up routes...")
-	r := routes.SetupRoutes(authHandler, bookHandler)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	log.Println("Starting the server on port :8080...")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start the server: %v", err)
+	a := New(cfg, db, logger, r, checks)
+	StartWorkers(a, services)
+	if err := a.Serve(ctx); err != nil {
+		logger.Fatal("Server stopped with error", zap.Error(err))
 	}
+	logger.Info("Server stopped gracefully")
 }
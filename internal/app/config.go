@@ -0,0 +1,222 @@
+package app
+
+import (
+	"Bookstore/internal/middleware"
+	"Bookstore/internal/notify"
+	"Bookstore/internal/routes"
+	"log"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerConfig настройки HTTP сервера и остановки, читаются из переменных окружения
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// DrainDelay — пауза между переключением readiness и остановкой приёма запросов,
+	// чтобы балансировщик успел убрать инстанс
+	DrainDelay      time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// LoadServerConfig читает HTTP_* переменные, для отсутствующих берёт значения по умолчанию
+func LoadServerConfig() ServerConfig {
+	return ServerConfig{
+		Addr:              envString("HTTP_ADDR", ":8080"),
+		ReadTimeout:       envDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout: envDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      envDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       envDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		DrainDelay:        envDuration("HTTP_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout:   envDuration("HTTP_SHUTDOWN_TIMEOUT", 30*time.Second),
+	}
+}
+
+// LoadRoutesConfig сроки устаревания версий API: API_LEGACY_DEPRECATED_AT и API_LEGACY_SUNSET
+// для маршрутов без префикса; API_V1_DEPRECATED_AT и API_V1_SUNSET — только после выхода /v2
+func LoadRoutesConfig() routes.Config {
+	cfg := routes.Config{
+		Legacy: middleware.Deprecation{
+			Since:     envDate("API_LEGACY_DEPRECATED_AT", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)),
+			Sunset:    envDate("API_LEGACY_SUNSET", time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)),
+			Successor: "/v1",
+		},
+	}
+	if since := envDate("API_V1_DEPRECATED_AT", time.Time{}); !since.IsZero() {
+		cfg.V1 = &middleware.Deprecation{Since: since, Sunset: envDate("API_V1_SUNSET", time.Time{}), Successor: "/v2"}
+	}
+	return cfg
+}
+
+// ReservationConfig TTL — сколько резерв держит товар, SweepInterval — как часто сборщик
+// освобождает истёкшие резервы
+type ReservationConfig struct {
+	TTL           time.Duration
+	SweepInterval time.Duration
+}
+
+// LoadReservationConfig читает RESERVATION_TTL и RESERVATION_SWEEP_INTERVAL
+func LoadReservationConfig() ReservationConfig {
+	return ReservationConfig{
+		TTL:           envDuration("RESERVATION_TTL", 15*time.Minute),
+		SweepInterval: envDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+	}
+}
+
+// ReorderConfig CheckInterval — как часто искать книги на точке заказа,
+// SalesWindowDays — за сколько дней считать скорость продаж в алертах
+type ReorderConfig struct {
+	CheckInterval   time.Duration
+	SalesWindowDays int
+}
+
+// LoadReorderConfig читает REORDER_CHECK_INTERVAL и REORDER_SALES_WINDOW_DAYS
+func LoadReorderConfig() ReorderConfig {
+	return ReorderConfig{
+		CheckInterval:   envDuration("REORDER_CHECK_INTERVAL", time.Hour),
+		SalesWindowDays: envInt("REORDER_SALES_WINDOW_DAYS", 30),
+	}
+}
+
+// ImportConfig MaxBytes — предельный размер загружаемого файла, SyncRows — файлы до стольких строк
+// обрабатываются прямо в запросе, PollInterval — как часто воркер проверяет очередь импорта,
+// MaxSilence — сколько воркер может молчать, прежде чем /readyz сочтёт его зависшим. Во время задачи
+// пульс идёт после каждой строки, а одна строка — несколько запросов к базе, поэтому это не 3*PollInterval
+type ImportConfig struct {
+	MaxBytes     int64
+	SyncRows     int
+	PollInterval time.Duration
+	MaxSilence   time.Duration
+}
+
+// LoadImportConfig читает IMPORT_MAX_BYTES, IMPORT_SYNC_ROWS, IMPORT_POLL_INTERVAL и IMPORT_MAX_SILENCE
+func LoadImportConfig() ImportConfig {
+	return ImportConfig{
+		MaxBytes:     int64(envInt("IMPORT_MAX_BYTES", 32<<20)),
+		SyncRows:     envInt("IMPORT_SYNC_ROWS", 500),
+		PollInterval: envDuration("IMPORT_POLL_INTERVAL", 5*time.Second),
+		MaxSilence:   envDuration("IMPORT_MAX_SILENCE", 2*time.Minute),
+	}
+}
+
+// OnixConfig Currency — валюта цен, которые берутся из ONIX (пустая — любая, розничная с налогом
+// предпочтительнее); Timeout — сколько может идти загрузка фида через API
+type OnixConfig struct {
+	Currency string
+	Timeout  time.Duration
+}
+
+// LoadOnixConfig читает ONIX_CURRENCY и ONIX_TIMEOUT
+func LoadOnixConfig() OnixConfig {
+	return OnixConfig{
+		Currency: envString("ONIX_CURRENCY", ""),
+		Timeout:  envDuration("ONIX_TIMEOUT", 30*time.Minute),
+	}
+}
+
+// ExportConfig Timeout — сколько может идти выгрузка каталога
+type ExportConfig struct {
+	Timeout time.Duration
+}
+
+// LoadExportConfig читает EXPORT_TIMEOUT
+func LoadExportConfig() ExportConfig {
+	return ExportConfig{
+		Timeout: envDuration("EXPORT_TIMEOUT", 30*time.Minute),
+	}
+}
+
+// LoadNotifier каналы алертов из NOTIFY_CHANNELS через запятую (по умолчанию log):
+// log — в лог приложения; email — SMTP_ADDR, SMTP_USERNAME, SMTP_PASSWORD, NOTIFY_EMAIL_FROM, NOTIFY_EMAIL_TO;
+// webhook — NOTIFY_WEBHOOK_URL. Канал без обязательных настроек пропускается с предупреждением
+func LoadNotifier() notify.Notifier {
+	var notifiers notify.Multi
+	for _, channel := range strings.Split(envString("NOTIFY_CHANNELS", "log"), ",") {
+		switch channel = strings.TrimSpace(channel); channel {
+		case "":
+		case "log":
+			notifiers = append(notifiers, notify.Log{})
+		case "email":
+			addr, from, to := os.Getenv("SMTP_ADDR"), os.Getenv("NOTIFY_EMAIL_FROM"), os.Getenv("NOTIFY_EMAIL_TO")
+			if addr == "" || from == "" || to == "" {
+				log.Printf("NOTIFY_CHANNELS: email needs SMTP_ADDR, NOTIFY_EMAIL_FROM and NOTIFY_EMAIL_TO, skipping")
+				continue
+			}
+			email := notify.Email{Addr: addr, From: from, To: strings.Split(to, ",")}
+			if user := os.Getenv("SMTP_USERNAME"); user != "" {
+				host, _, _ := strings.Cut(addr, ":")
+				email.Auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+			}
+			notifiers = append(notifiers, email)
+		case "webhook":
+			url := os.Getenv("NOTIFY_WEBHOOK_URL")
+			if url == "" {
+				log.Printf("NOTIFY_CHANNELS: webhook needs NOTIFY_WEBHOOK_URL, skipping")
+				continue
+			}
+			notifiers = append(notifiers, notify.NewWebhook(url))
+		default:
+			log.Printf("NOTIFY_CHANNELS: unknown channel %q, skipping", channel)
+		}
+	}
+	return notifiers
+}
+
+// autoMigrate включается переменной окружения DB_AUTO_MIGRATE=true
+func autoMigrate() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("DB_AUTO_MIGRATE"))
+	return enabled
+}
+
+func envString(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %d: %v", key, value, fallback, err)
+		return fallback
+	}
+	return n
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %s: %v", key, value, fallback, err)
+		return fallback
+	}
+	return d
+}
+
+// envDate дата в формате 2006-01-02 (UTC)
+func envDate(key string, fallback time.Time) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %s: %v", key, value, fallback.Format(time.DateOnly), err)
+		return fallback
+	}
+	return t
+}
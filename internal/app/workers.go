@@ -0,0 +1,99 @@
+package app
+
+import (
+	"Bookstore/internal/health"
+	"Bookstore/internal/logging"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+// StartWorkers запускает фоновые воркеры приложения
+func StartWorkers(a *App, services *Services) {
+	cfg := LoadReservationConfig()
+	// Пропуск трёх проходов подряд — воркер завис
+	a.Go("reservation-sweeper", 3*cfg.SweepInterval, func(ctx context.Context, hb *health.Heartbeat) {
+		sweepReservations(logging.WithLogger(ctx, a.logger), hb, services, cfg.SweepInterval)
+	})
+
+	reorder := LoadReorderConfig()
+	a.Go("reorder-check", 3*reorder.CheckInterval, func(ctx context.Context, hb *health.Heartbeat) {
+		checkReorderPoints(logging.WithLogger(ctx, a.logger), hb, services, reorder.CheckInterval)
+	})
+
+	imports := LoadImportConfig()
+	a.Go("book-import", max(imports.MaxSilence, 3*imports.PollInterval), func(ctx context.Context, hb *health.Heartbeat) {
+		runImports(logging.WithLogger(ctx, a.logger), hb, services, imports.PollInterval)
+	})
+}
+
+// sweepReservations раз в interval переводит истёкшие резервы в expired; ошибка одного прохода
+// не останавливает воркер, следующий проход попробует снова
+func sweepReservations(ctx context.Context, hb *health.Heartbeat, services *Services, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		hb.Beat()
+		n, err := services.Reservations.ExpireReservations(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			logging.FromContext(ctx).Error("Failed to expire reservations", zap.Error(err))
+		case n > 0:
+			logging.FromContext(ctx).Info("Expired reservations released", zap.Int64("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkReorderPoints раз в interval ищет книги, опустившиеся до точки заказа, и отправляет алерт
+func checkReorderPoints(ctx context.Context, hb *health.Heartbeat, services *Services, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		hb.Beat()
+		n, err := services.Reorder.CheckLowStock(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			logging.FromContext(ctx).Error("Reorder check failed", zap.Error(err))
+		case n > 0:
+			logging.FromContext(ctx).Info("Low stock alert sent", zap.Int("books", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runImports раз в interval берёт задачи импорта из очереди и обрабатывает их одну за другой,
+// пока очередь не опустеет; во время длинной задачи пульс идёт после каждой строки
+func runImports(ctx context.Context, hb *health.Heartbeat, services *Services, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		hb.Beat()
+		for ctx.Err() == nil {
+			ran, err := services.BookImport.RunNextImport(ctx, hb.Beat)
+			if err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("Book import failed", zap.Error(err))
+			}
+			if !ran || err != nil {
+				break
+			}
+			hb.Beat()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/sheet"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func runBook(e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: book import|export|onix [flags]")
+	}
+
+	switch args[0] {
+	case "import":
+		return bookImport(e, args[1:])
+	case "export":
+		return bookExport(e, args[1:])
+	case "onix":
+		return bookOnix(e, args[1:])
+	default:
+		return fmt.Errorf("unknown book command %q", args[0])
+	}
+}
+
+// bookImport загружает CSV или XLSX тем же сервисом, что и POST /admin/books/import: те же колонки,
+// сопоставление по ISBN или ID и отчёт об ошибках строк. Большой файл ставится в очередь воркеру
+func bookImport(e *env, args []string) error {
+	fs := flag.NewFlagSet("book import", flag.ContinueOnError)
+	file := fs.String("file", "", "CSV or XLSX file to import")
+	format := fs.String("format", "", "csv or xlsx (by default from the file extension)")
+	match := fs.String("match", models.ImportMatchISBN, "match existing books by isbn or id")
+	dryRun := fs.Bool("dry-run", false, "only validate the rows, change nothing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	job := &models.ImportJob{Format: *format, Match: *match, DryRun: *dryRun}
+	if job.Format == "" {
+		job.Format = sheet.FormatOf(*file)
+	}
+
+	job, err = e.imports.Import(actor.With(context.Background(), "cli"), job, data)
+	if err != nil {
+		return err
+	}
+	if job.ID != 0 {
+		_, _ = fmt.Fprintf(e.out, "rows: %d, queued as import job %d\n", job.TotalRows, job.ID)
+		return nil
+	}
+	for _, rowErr := range job.Errors {
+		_, _ = fmt.Fprintf(e.out, "row %d (%s): %s\n", rowErr.Row, rowErr.Key, rowErrorText(rowErr))
+	}
+	_, _ = fmt.Fprintf(e.out, "rows: %d, created: %d, updated: %d, failed: %d\n", job.TotalRows, job.Created, job.Updated, job.Failed)
+	return nil
+}
+
+// rowErrorText сообщение ошибки записи вместе с ошибками полей
+func rowErrorText(rowErr models.ImportRowError) string {
+	parts := []string{rowErr.Message}
+	for _, fe := range rowErr.Errors {
+		parts = append(parts, fe.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// bookOnix загружает ONIX 3.0 фид; файл читается потоково, ошибки записей печатаются перед итогом
+func bookOnix(e *env, args []string) error {
+	fs := flag.NewFlagSet("book onix", flag.ContinueOnError)
+	file := fs.String("file", "", "ONIX 3.0 XML file, - for stdin")
+	dryRun := fs.Bool("dry-run", false, "only validate the records, change nothing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	var feed io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer func(f *os.File) {
+			_ = f.Close()
+		}(f)
+		feed = f
+	}
+
+	summary, err := e.onix.Ingest(actor.With(context.Background(), "cli"), feed, *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, rowErr := range summary.Errors {
+		_, _ = fmt.Fprintf(e.out, "product %d (%s): %s\n", rowErr.Row, rowErr.Key, rowErrorText(rowErr))
+	}
+	if summary.ErrorsTruncated {
+		_, _ = fmt.Fprintln(e.out, "... more errors not shown")
+	}
+	if summary.Error != "" {
+		_, _ = fmt.Fprintf(e.out, "feed stopped: %s\n", summary.Error)
+	}
+	_, _ = fmt.Fprintf(e.out, "products: %d, created: %d, updated: %d, deleted: %d, skipped: %d, failed: %d\n",
+		summary.Products, summary.Created, summary.Updated, summary.Deleted, summary.Skipped, summary.Failed)
+	return nil
+}
+
+// bookExport выгружает каталог в колонках ExportColumns, как GET /admin/books/export
+func bookExport(e *env, args []string) error {
+	fs := flag.NewFlagSet("book export", flag.ContinueOnError)
+	out := fs.String("out", "", "output file (stdout by default)")
+	format := fs.String("format", "", "csv or xlsx (by default from the -out extension, csv for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = sheet.CSV
+		if *out != "" {
+			*format = sheet.FormatOf(*out)
+		}
+	}
+
+	w := e.out
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer func(f *os.File) {
+			_ = f.Close()
+		}(f)
+		w = f
+	}
+
+	// Книги читаются курсором и пишутся сразу, весь каталог в памяти не собирается
+	sw, err := sheet.NewWriter(*format, w)
+	if err != nil {
+		return err
+	}
+	if err := sw.Write(service.ExportColumns); err != nil {
+		return err
+	}
+	err = e.books.ExportBooks(context.Background(), "", func(book *models.Book) error {
+		return sw.Write(service.ExportRecord(book))
+	})
+	if err != nil {
+		return err
+	}
+	return sw.Close()
+}
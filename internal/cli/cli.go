@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"Bookstore/internal/app"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/migrations"
+	"Bookstore/internal/service"
+	config "Bookstore/pkg/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+const usage = `usage: bookstore <command> [arguments]
+
+commands:
+  serve                                  start the HTTP server
+  migrate up|down [n]|status|goto <v>    manage the database schema
+  user create -username U -password P [-admin]
+  user set-role -username U -role user|admin
+  book import -file books.csv [-format csv|xlsx] [-match isbn|id] [-dry-run]
+  book export [-out books.csv] [-format csv|xlsx]
+  book onix -file feed.xml [-dry-run]    load an ONIX 3.0 feed (- reads stdin)
+  token issue -username U
+  i18n check                             verify every locale has every message key
+  openapi check                          verify every route is described in openapi.json`
+
+// env — зависимости, которые нужны командам, работающим с базой
+type env struct {
+	db      *sql.DB
+	logger  *zap.Logger
+	auth    *service.AuthService
+	books   service.BOokService
+	imports service.BookImportService
+	onix    service.OnixService
+	out     io.Writer
+}
+
+// Run разбирает аргументы командной строки и выполняет подкоманду
+func Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "serve":
+		app.Run()
+		return nil
+	case "migrate":
+		return withEnv(func(e *env) error {
+			migrator, err := migrations.New(e.db, e.logger)
+			if err != nil {
+				return err
+			}
+			return migrations.RunCommand(context.Background(), migrator, args[1:], e.out)
+		})
+	case "user":
+		return withEnv(func(e *env) error { return runUser(e, args[1:]) })
+	case "book":
+		return withEnv(func(e *env) error { return runBook(e, args[1:]) })
+	case "token":
+		return withEnv(func(e *env) error { return runToken(e, args[1:]) })
+	case "i18n":
+		return runI18n(args[1:], os.Stdout)
+	case "openapi":
+		return runOpenAPI(args[1:], os.Stdout)
+	case "help", "-h", "--help":
+		_, _ = fmt.Fprintln(os.Stdout, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+// withEnv подключается к базе, собирает сервисы и закрывает всё после fn
+func withEnv(fn func(e *env) error) error {
+	db, err := config.ConnectDB()
+	if err != nil {
+		return err
+	}
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+
+	logger, err := logging.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %v", err)
+	}
+	defer func(logger *zap.Logger) {
+		_ = logger.Sync()
+	}(logger)
+
+	services := app.InitServices(db, metrics.Nop())
+	return fn(&env{db: db, logger: logger, auth: services.Auth, books: services.Books, imports: services.BookImport, onix: services.Onix, out: os.Stdout})
+}
@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"Bookstore/internal/i18n"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// runI18n проверка каталога переводов; база не нужна, поэтому без withEnv
+func runI18n(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "check" {
+		return errors.New("usage: bookstore i18n check")
+	}
+	if err := i18n.Check(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "ok: %d keys in %s\n", len(i18n.Keys()), strings.Join(i18n.Locales(), ", "))
+	return err
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"Bookstore/internal/health"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/openapi"
+	"Bookstore/internal/routes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// runOpenAPI сверяет маршруты роутера с openapi.json; для CI, база не нужна —
+// хендлеры не вызываются, нужен только список маршрутов
+func runOpenAPI(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "check" {
+		return errors.New("usage: bookstore openapi check")
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := routes.SetupRoutes(zap.NewNop(), health.NewRegistry(), metrics.NewPrometheus(), routes.Config{}, routes.Handlers{})
+	if err := openapi.Check(router.Routes(), "/v1"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "ok: %d routes documented\n", len(router.Routes()))
+	return err
+}
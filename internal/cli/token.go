@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"Bookstore/internal/middleware"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func runToken(e *env, args []string) error {
+	if len(args) == 0 || args[0] != "issue" {
+		return errors.New("usage: token issue -username U")
+	}
+
+	fs := flag.NewFlagSet("token issue", flag.ContinueOnError)
+	username := fs.String("username", "", "username to issue the token for")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	// Пользователь должен существовать, иначе токен выдавать некому
+	user, err := e.auth.GetUserByName(context.Background(), *username)
+	if err != nil {
+		return err
+	}
+
+	token, err := middleware.GenerateAccessToken(user.Username, user.Role)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(e.out, token)
+	return nil
+}
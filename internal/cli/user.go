@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"Bookstore/internal/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func runUser(e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: user create|set-role [flags]")
+	}
+
+	switch args[0] {
+	case "create":
+		return userCreate(e, args[1:])
+	case "set-role":
+		return userSetRole(e, args[1:])
+	default:
+		return fmt.Errorf("unknown user command %q", args[0])
+	}
+}
+
+// userCreate создаёт пользователя через AuthService; -admin нужен для первого администратора
+func userCreate(e *env, args []string) error {
+	fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := fs.String("username", "", "username")
+	password := fs.String("password", "", "password")
+	admin := fs.Bool("admin", false, "create the user with the admin role")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	user := &models.User{Username: *username, Password: *password, Role: models.RoleUser}
+	if *admin {
+		user.Role = models.RoleAdmin
+	}
+	if err := e.auth.RegisterUser(context.Background(), user); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(e.out, "user %s created with role %s\n", user.Username, user.Role)
+	return nil
+}
+
+func userSetRole(e *env, args []string) error {
+	fs := flag.NewFlagSet("user set-role", flag.ContinueOnError)
+	username := fs.String("username", "", "username")
+	role := fs.String("role", "", "new role: user or admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := e.auth.SetUserRole(context.Background(), *username, *role)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(e.out, "user %s now has role %s\n", user.Username, user.Role)
+	return nil
+}
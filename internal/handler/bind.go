@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"Bookstore/internal/mergepatch"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// bindJSON разбирает тело запроса; ошибки типов и binding тегов превращает в ошибки полей
+func bindJSON(c *gin.Context, dst any) error {
+	err := c.ShouldBindJSON(dst)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &typeErr):
+		return validation.TypeMismatch(typeErr.Field, typeErr.Type.String()).Wrap(err)
+	case errors.As(err, &validationErrs):
+		return wrong.Validation(validation.Fields(validationErrs)...).Wrap(err)
+	default:
+		return wrong.ErrMalformedBody.Wrap(err)
+	}
+}
+
+// readMergePatch тело PATCH запроса: application/merge-patch+json или application/json
+func readMergePatch(c *gin.Context) ([]byte, error) {
+	switch c.ContentType() {
+	case mergepatch.ContentType, "application/json":
+	default:
+		return nil, wrong.ErrUnsupportedMedia
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, wrong.ErrMalformedBody.Wrap(err)
+	}
+	return body, nil
+}
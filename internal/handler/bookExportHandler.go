@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/sheet"
+	"Bookstore/internal/wrong"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Форматы выгрузки каталога и их Content-Type
+const exportJSONL = "jsonl"
+
+var exportContentTypes = map[string]string{
+	sheet.CSV:   "text/csv; charset=utf-8",
+	exportJSONL: "application/x-ndjson",
+	sheet.XLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportFlushEvery через сколько книг отправлять клиенту накопленное
+const exportFlushEvery = 1000
+
+type BookExportHandler struct {
+	service service.BOokService
+	timeout time.Duration
+}
+
+// NewBookExportHandler timeout — сколько может идти одна выгрузка
+func NewBookExportHandler(s service.BOokService, timeout time.Duration) *BookExportHandler {
+	return &BookExportHandler{service: s, timeout: timeout}
+}
+
+// Export ?format=csv|jsonl|xlsx (по умолчанию csv), фильтры как у GET /books: ?q= и язык из ?lang= или Accept-Language.
+// Книги читаются из базы порциями и сразу пишутся в ответ (chunked), каталог целиком в памяти не собирается
+func (h *BookExportHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", sheet.CSV)
+	if _, ok := exportContentTypes[format]; !ok {
+		respondWithError(c, wrong.Validation(wrong.FieldError{Field: "format", Code: "oneof", Param: "csv jsonl xlsx",
+			Message: "format must be one of csv, jsonl, xlsx"}))
+		return
+	}
+	ctx := c.Request.Context()
+	// Выгрузка большого каталога идёт дольше обычного таймаута записи сервера
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(h.timeout))
+
+	prefs := languagePreferences(c)
+	var out bookWriter
+	n := 0
+	err := h.service.ExportBooks(ctx, c.Query("q"), func(book *models.Book) error {
+		if out == nil {
+			var err error
+			if out, err = startExport(c, format); err != nil {
+				return err
+			}
+		}
+		book.Localize(prefs)
+		if err := out.Write(book); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil && out == nil {
+		out, err = startExport(c, format)
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		respondWithError(c, err)
+		return
+	}
+	logging.FromContext(ctx).Error("Book export aborted", zap.String("format", format), zap.Int("books", n), zap.Error(err))
+	abortResponse(c)
+}
+
+// abortResponse заголовки уже отправлены, ошибку клиенту не передать: рвём соединение,
+// чтобы клиент не принял обрезанный файл за целый. HTTP/2 соединение не отдаёт — там сбрасывается поток
+func abortResponse(c *gin.Context) {
+	conn, _, err := http.NewResponseController(c.Writer).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	_ = conn.Close()
+	c.Abort()
+}
+
+// bookWriter пишет книги в тело ответа в одном из форматов выгрузки
+type bookWriter interface {
+	Write(book *models.Book) error
+	Close() error
+}
+
+// startExport заголовки ответа и начало файла; вызывается, когда первая порция книг уже прочитана,
+// чтобы ошибка базы до этого момента ушла клиенту обычным problem+json
+func startExport(c *gin.Context, format string) (bookWriter, error) {
+	c.Header("Content-Type", exportContentTypes[format])
+	c.Header("Content-Disposition", `attachment; filename="books.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	if format == exportJSONL {
+		return jsonlWriter{enc: json.NewEncoder(c.Writer)}, nil
+	}
+	w, err := sheet.NewWriter(format, c.Writer)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Write(service.ExportColumns); err != nil {
+		return nil, err
+	}
+	return sheetWriter{w: w}, nil
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j jsonlWriter) Write(book *models.Book) error {
+	return j.enc.Encode(book)
+}
+
+func (j jsonlWriter) Close() error {
+	return nil
+}
+
+type sheetWriter struct {
+	w sheet.Writer
+}
+
+func (s sheetWriter) Write(book *models.Book) error {
+	return s.w.Write(service.ExportRecord(book))
+}
+
+func (s sheetWriter) Close() error {
+	return s.w.Close()
+}
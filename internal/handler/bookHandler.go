@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"Bookstore/internal/i18n"
+	"Bookstore/internal/logging"
 	"Bookstore/internal/models"
 	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
 	"github.com/gin-gonic/gin"
-	"log"
+	"go.uber.org/zap"
 	"net/http"
 	"strconv"
 )
@@ -20,46 +23,89 @@ func NewBookHandler(s service.BOokService) *BookHandler {
 
 func (h *BookHandler) CreateBookHandler(c *gin.Context) {
 	var book models.Book
-	if err := c.ShouldBindJSON(&book); err != nil {
-		log.Printf("Error connection to Bindjson: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "wrong data"})
+	if err := bindJSON(c, &book); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
-	if err := h.service.CreateBook(&book); err != nil {
-		log.Printf("Error create Book in service: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+	if err := h.service.CreateBook(c.Request.Context(), &book); err != nil {
+		respondWithError(c, err)
 		return
 	}
+	c.Header("ETag", bookETag(&book))
 	c.JSON(http.StatusOK, gin.H{"data": book})
 
 }
 
+// GetAllBook ?q= ищет по названию, подзаголовку и описанию на всех языках
 func (h *BookHandler) GetAllBook(c *gin.Context) {
-	books, err := h.service.GetAllBook()
+	books, err := h.service.SearchBooks(c.Request.Context(), c.Query("q"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		respondWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": books})
+	prefs := languagePreferences(c)
+	for _, book := range books {
+		book.Localize(prefs)
+	}
+	hideCostPrice(c, books...)
+	render(c, http.StatusOK, payload{key: "data", value: books, root: "books", item: "book", table: bookTable(books...)})
 }
 
 func (h *BookHandler) GetBookByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong ID"})
+		respondWithError(c, wrong.ErrInvalidBookID.Wrap(err))
 		return
 	}
-	book, err := h.service.GetBookByID(id)
+	book, err := h.service.GetBookByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		respondWithError(c, err)
 		return
 	}
-	if book == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "book not found"})
+	format, ok := negotiate(c)
+	if !ok {
+		respondWithError(c, wrong.ErrNotAcceptable)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": book})
+	book.Localize(languagePreferences(c))
+	hideCostPrice(c, book)
+	c.Header("Content-Language", book.Language)
+	etag := formatETag(bookETag(book), format)
+	if notModified(c, etag) {
+		return
+	}
+	c.Header("ETag", etag)
+	renderAs(c, format, http.StatusOK, payload{key: "data", value: book, root: "book", table: bookTable(book)})
+}
+
+// hideCostPrice закупочную цену видят только админы
+func hideCostPrice(c *gin.Context, books ...*models.Book) {
+	if c.GetString("role") == models.RoleAdmin {
+		return
+	}
+	for _, book := range books {
+		book.CostPrice = nil
+	}
+}
+
+// bookTable CSV ответа с книгами: те же колонки, что у выгрузки каталога
+func bookTable(books ...*models.Book) func() [][]any {
+	return func() [][]any {
+		rows := [][]any{service.ExportColumns}
+		for _, book := range books {
+			rows = append(rows, service.ExportRecord(book))
+		}
+		return rows
+	}
+}
+
+// languagePreferences ?lang= важнее Accept-Language
+func languagePreferences(c *gin.Context) []string {
+	if lang := c.Query("lang"); lang != "" {
+		return i18n.Preferences(lang)
+	}
+	return i18n.Preferences(c.GetHeader("Accept-Language"))
 }
 func (h *BookHandler) UpdateBookHandler(c *gin.Context) {
 	var book models.Book
@@ -67,35 +113,70 @@ func (h *BookHandler) UpdateBookHandler(c *gin.Context) {
 	// Получаем ID из параметра пути и конвертируем его в int
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		log.Printf("Invalid book ID: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		respondWithError(c, wrong.ErrInvalidBookID.Wrap(err))
 		return
 	}
-	book.ID = id // Устанавливаем ID для обновления
 
-	if err := c.ShouldBindJSON(&book); err != nil {
-		log.Printf("Error in ShouldBindJSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "wrong data"})
+	if err := bindJSON(c, &book); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	book.ID = id // ID из пути важнее ID в теле
+	// Версию задаёт только If-Match, поле version в теле игнорируется
+	if book.Version, err = ifMatchVersion(c, wrong.ErrVersionMismatch); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
-	log.Printf("Received data for update: %+v", book)
-	if err := h.service.UpdateBook(&book); err != nil {
-		//log.Printf("Error in UpdateBook service: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	logging.FromContext(c.Request.Context()).Debug("Received data for update", zap.Any("book", book))
+	if err := h.service.UpdateBook(c.Request.Context(), &book); err != nil {
+		respondWithError(c, err)
 		return
 	}
+	c.Header("ETag", bookETag(&book))
+	c.JSON(http.StatusOK, gin.H{"data": book})
+}
+
+// PatchBookHandler RFC 7396 merge patch: меняются только присланные поля
+func (h *BookHandler) PatchBookHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidBookID.Wrap(err))
+		return
+	}
+	version, err := ifMatchVersion(c, wrong.ErrVersionMismatch)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	patch, err := readMergePatch(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	book, err := h.service.PatchBook(c.Request.Context(), id, version, patch)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.Header("ETag", bookETag(book))
 	c.JSON(http.StatusOK, gin.H{"data": book})
 }
 
 func (h *BookHandler) DeleteBookHandler(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong ID"})
+		respondWithError(c, wrong.ErrInvalidBookID.Wrap(err))
+		return
+	}
+	version, err := ifMatchVersion(c, wrong.ErrVersionMismatch)
+	if err != nil {
+		respondWithError(c, err)
 		return
 	}
-	if err := h.service.DeleteBook(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+	if err := h.service.DeleteBook(c.Request.Context(), id, version); err != nil {
+		respondWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": true})
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"Bookstore/internal/i18n"
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/sheet"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type BookImportHandler struct {
+	service  service.BookImportService
+	maxBytes int64
+}
+
+// NewBookImportHandler maxBytes — предельный размер тела запроса с файлом
+func NewBookImportHandler(s service.BookImportService, maxBytes int64) *BookImportHandler {
+	return &BookImportHandler{service: s, maxBytes: maxBytes}
+}
+
+// Import multipart/form-data: file — CSV или XLSX; format — csv|xlsx (по умолчанию по расширению);
+// match — isbn|id; dry_run — true, чтобы только проверить; mapping — JSON {"поле": "колонка"}.
+// Готовый отчёт — 200, задача в очереди — 202 с Location для опроса
+func (h *BookImportHandler) Import(c *gin.Context) {
+	if c.ContentType() != "multipart/form-data" {
+		respondWithError(c, wrong.ErrUnsupportedMedia)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes)
+
+	data, filename, err := readImportFile(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	job := &models.ImportJob{Format: c.PostForm("format"), Match: c.PostForm("match")}
+	if job.Format == "" {
+		job.Format = sheet.FormatOf(filename)
+	}
+	if raw := c.PostForm("dry_run"); raw != "" {
+		if job.DryRun, err = strconv.ParseBool(raw); err != nil {
+			respondWithError(c, validation.TypeMismatch("dry_run", "boolean").Wrap(err))
+			return
+		}
+	}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &job.Mapping); err != nil {
+			respondWithError(c, validation.TypeMismatch("mapping", "object").Wrap(err))
+			return
+		}
+	}
+
+	job, err = h.service.Import(c.Request.Context(), job, data)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	localizeRowErrors(c, job.Errors)
+	if job.ID == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": job})
+		return
+	}
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+strconv.Itoa(job.ID))
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+// GetImportJob прогресс и отчёт фоновой задачи импорта
+func (h *BookImportHandler) GetImportJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidImportJobID.Wrap(err))
+		return
+	}
+	job, err := h.service.GetImportJob(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	localizeRowErrors(c, job.Errors)
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// readImportFile содержимое поля file и имя файла
+func readImportFile(c *gin.Context) ([]byte, string, error) {
+	fh, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return nil, "", wrong.ErrImportTooLarge.Wrap(err)
+	case errors.Is(err, http.ErrMissingFile):
+		return nil, "", wrong.ErrImportFileRequired
+	case err != nil:
+		return nil, "", wrong.ErrImportFileInvalid.Wrap(err)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, "", wrong.ErrImportFileInvalid.Wrap(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", wrong.ErrImportFileInvalid.Wrap(err)
+	}
+	return data, fh.Filename, nil
+}
+
+// localizeRowErrors сообщения в отчёте хранятся на английском, клиенту — на языке запроса
+func localizeRowErrors(c *gin.Context, rowErrors []models.ImportRowError) {
+	locale := i18n.FromContext(c.Request.Context())
+	for i, rowErr := range rowErrors {
+		e := i18n.Localize(locale, &wrong.Error{Code: rowErr.Code, Message: rowErr.Message, Fields: rowErr.Errors})
+		rowErrors[i].Message, rowErrors[i].Errors = e.Message, e.Fields
+	}
+}
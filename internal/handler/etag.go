@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// bookETag сильный ETag представления книги: версия строки и язык метаданных,
+// потому что ответ зависит от Accept-Language. Остаток и доступный остаток меняют движения склада
+// и резервы, не трогая версию, поэтому они тоже входят в ETag; для If-Match важна только версия
+func bookETag(book *models.Book) string {
+	tag := fmt.Sprintf("v%d", book.Version)
+	if book.Language != "" {
+		tag += "-" + book.Language
+	}
+	tag += fmt.Sprintf("-q%d", book.Quantity)
+	if book.Available != nil {
+		tag += fmt.Sprintf("-a%d", *book.Available)
+	}
+	return `"` + tag + `"`
+}
+
+// userETag сильный ETag пользователя — версия строки
+func userETag(user *models.User) string {
+	return fmt.Sprintf(`"v%d"`, user.Version)
+}
+
+// ifMatchVersion версия из If-Match для изменения книги или пользователя. Без заголовка — 428:
+// изменение вслепую затёрло бы чужую правку; "*" — осознанно любая версия (0).
+// Слабые и чужие ETag не могут совпасть, для них сразу stale (412)
+func ifMatchVersion(c *gin.Context, stale error) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, wrong.ErrPreconditionRequired
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	// Из списка берём первый ETag: версия одна, разные языки и форматы дают одну версию
+	tag, _, _ := strings.Cut(header, ",")
+	tag = strings.TrimSpace(tag)
+	if strings.HasPrefix(tag, "W/") {
+		return 0, stale
+	}
+	digits, _, _ := strings.Cut(strings.TrimPrefix(strings.Trim(tag, `"`), "v"), "-")
+	version, err := strconv.Atoi(digits)
+	if err != nil || version <= 0 {
+		return 0, stale
+	}
+	return version, nil
+}
+
+// notModified true, если If-None-Match совпал с etag (слабое сравнение); тогда ответ 304 уже записан
+func notModified(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			c.Header("ETag", etag)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type LocationHandler struct {
+	service service.LocationService
+}
+
+func NewLocationHandler(s service.LocationService) *LocationHandler {
+	return &LocationHandler{service: s}
+}
+
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	var location models.Location
+	if err := bindJSON(c, &location); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if err := h.service.CreateLocation(c.Request.Context(), &location); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": location})
+}
+
+func (h *LocationHandler) GetAllLocations(c *gin.Context) {
+	locations, err := h.service.GetAllLocations(c.Request.Context())
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": locations})
+}
+
+// Availability ?lat= и ?lon= — координаты покупателя для выбора ближайшей точки,
+// ?quantity= — сколько экземпляров нужно (по умолчанию 1)
+func (h *LocationHandler) Availability(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidBookID.Wrap(err))
+		return
+	}
+	near, err := nearParams(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	quantity := 1
+	if raw := c.Query("quantity"); raw != "" {
+		if quantity, err = strconv.Atoi(raw); err != nil || quantity <= 0 {
+			respondWithError(c, wrong.Validation(wrong.FieldError{Field: "quantity", Code: "gt", Param: "0", Message: "quantity must be greater than 0"}))
+			return
+		}
+	}
+
+	availability, err := h.service.Availability(c.Request.Context(), id, near, quantity)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": availability})
+}
+
+// nearParams lat и lon передаются вместе; без них — nil
+func nearParams(c *gin.Context) (*service.Point, error) {
+	rawLat, rawLon := c.Query("lat"), c.Query("lon")
+	if rawLat == "" && rawLon == "" {
+		return nil, nil
+	}
+
+	var fields []wrong.FieldError
+	coordinate := func(field, raw string, limit float64) float64 {
+		if raw == "" {
+			fields = append(fields, wrong.FieldError{Field: field, Code: "required", Message: field + " is required"})
+			return 0
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		switch {
+		case err != nil:
+			fields = append(fields, wrong.FieldError{Field: field, Code: "type", Param: "number", Message: field + " must be a number"})
+		case v < -limit:
+			fields = append(fields, wrong.FieldError{Field: field, Code: "gte", Param: strconv.FormatFloat(-limit, 'f', -1, 64),
+				Message: field + " must be at least " + strconv.FormatFloat(-limit, 'f', -1, 64)})
+		case v > limit:
+			fields = append(fields, wrong.FieldError{Field: field, Code: "lte", Param: strconv.FormatFloat(limit, 'f', -1, 64),
+				Message: field + " must be at most " + strconv.FormatFloat(limit, 'f', -1, 64)})
+		}
+		return v
+	}
+	near := &service.Point{Latitude: coordinate("lat", rawLat, 90), Longitude: coordinate("lon", rawLon, 180)}
+	if len(fields) > 0 {
+		return nil, wrong.Validation(fields...)
+	}
+	return near, nil
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"Bookstore/internal/service"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OnixHandler struct {
+	service service.OnixService
+	timeout time.Duration
+}
+
+// NewOnixHandler timeout — сколько может идти одна загрузка фида
+func NewOnixHandler(s service.OnixService, timeout time.Duration) *OnixHandler {
+	return &OnixHandler{service: s, timeout: timeout}
+}
+
+// Ingest тело запроса — сам фид (application/xml или text/xml, можно с Content-Encoding: gzip);
+// ?dry_run=true — только проверить записи. Фид читается потоково, пока идёт загрузка,
+// поэтому серверные таймауты чтения и записи для этого запроса продлеваются до timeout
+func (h *OnixHandler) Ingest(c *gin.Context) {
+	switch c.ContentType() {
+	case "application/xml", "text/xml":
+	default:
+		respondWithError(c, wrong.ErrUnsupportedMedia)
+		return
+	}
+	dryRun := false
+	if raw := c.Query("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			respondWithError(c, validation.TypeMismatch("dry_run", "boolean").Wrap(err))
+			return
+		}
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(h.timeout)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+
+	var feed io.Reader = c.Request.Body
+	if strings.EqualFold(c.GetHeader("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			respondWithError(c, wrong.ErrOnixInvalid.Wrap(err))
+			return
+		}
+		defer func() {
+			_ = zr.Close()
+		}()
+		feed = zr
+	}
+
+	summary, err := h.service.Ingest(c.Request.Context(), feed, dryRun)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	localizeRowErrors(c, summary.Errors)
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
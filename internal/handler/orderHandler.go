@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type OrderHandler struct {
+	service service.OrderService
+}
+
+func NewOrderHandler(s service.OrderService) *OrderHandler {
+	return &OrderHandler{service: s}
+}
+
+// checkoutRequest тело POST /checkout: точка, с которой списывается товар, и строки заказа,
+// либо только reservation_id — оплата резерва. Цены берутся из каталога, а не из запроса
+type checkoutRequest struct {
+	ReservationID int `json:"reservation_id"`
+	LocationID    int `json:"location_id"`
+	Items         []struct {
+		BookID   int `json:"book_id"`
+		Quantity int `json:"quantity"`
+	} `json:"items"`
+}
+
+func (h *OrderHandler) Checkout(c *gin.Context) {
+	var req checkoutRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	order := &models.Order{ReservationID: req.ReservationID, LocationID: req.LocationID, Items: make([]models.OrderLine, 0, len(req.Items))}
+	for _, item := range req.Items {
+		order.Items = append(order.Items, models.OrderLine{BookID: item.BookID, Quantity: item.Quantity})
+	}
+	if err := h.service.Checkout(c.Request.Context(), order); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": order})
+}
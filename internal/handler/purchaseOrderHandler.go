@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type PurchaseOrderHandler struct {
+	service service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(s service.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: s}
+}
+
+// purchaseOrderRequest тело POST и PUT /admin/purchase-orders; статус, номера строк
+// и полученное количество задаёт сервер
+type purchaseOrderRequest struct {
+	SupplierID int    `json:"supplier_id"`
+	LocationID int    `json:"location_id"`
+	Notes      string `json:"notes"`
+	Lines      []struct {
+		BookID   int     `json:"book_id"`
+		Quantity int     `json:"quantity"`
+		UnitCost float64 `json:"unit_cost"`
+	} `json:"lines"`
+}
+
+func (r *purchaseOrderRequest) order() *models.PurchaseOrder {
+	po := &models.PurchaseOrder{SupplierID: r.SupplierID, LocationID: r.LocationID, Notes: r.Notes,
+		Lines: make([]models.PurchaseOrderLine, 0, len(r.Lines))}
+	for _, line := range r.Lines {
+		po.Lines = append(po.Lines, models.PurchaseOrderLine{BookID: line.BookID, Quantity: line.Quantity, UnitCost: line.UnitCost})
+	}
+	return po
+}
+
+// receiptRequest тело POST /admin/purchase-orders/:id/receipts
+type receiptRequest struct {
+	Lines []models.ReceiptLine `json:"lines"`
+}
+
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req purchaseOrderRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	po := req.order()
+	if err := h.service.CreatePurchaseOrder(c.Request.Context(), po); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": po})
+}
+
+func (h *PurchaseOrderHandler) GetPurchaseOrderByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidPurchaseOrderID.Wrap(err))
+		return
+	}
+	po, err := h.service.GetPurchaseOrderByID(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": po})
+}
+
+// GetPurchaseOrders ?status=, ?supplier_id= и постранично (?limit=, ?before=); строки заказов не включаются
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
+	limit, before, err := pageParams(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	var supplierID int
+	if raw := c.Query("supplier_id"); raw != "" {
+		if supplierID, err = strconv.Atoi(raw); err != nil || supplierID <= 0 {
+			respondWithError(c, wrong.Validation(wrong.FieldError{Field: "supplier_id", Code: "integer", Message: "supplier_id must be a positive integer"}))
+			return
+		}
+	}
+
+	orders, err := h.service.GetPurchaseOrders(c.Request.Context(), c.Query("status"), supplierID, before, limit)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	response := gin.H{"data": orders}
+	if len(orders) == limit {
+		response["next_before"] = orders[len(orders)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *PurchaseOrderHandler) UpdatePurchaseOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidPurchaseOrderID.Wrap(err))
+		return
+	}
+	var req purchaseOrderRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	po := req.order()
+	po.ID = id
+	if err := h.service.UpdatePurchaseOrder(c.Request.Context(), po); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": po})
+}
+
+func (h *PurchaseOrderHandler) SendPurchaseOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidPurchaseOrderID.Wrap(err))
+		return
+	}
+	po, err := h.service.SendPurchaseOrder(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": po})
+}
+
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidPurchaseOrderID.Wrap(err))
+		return
+	}
+	var req receiptRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	po, err := h.service.ReceivePurchaseOrder(c.Request.Context(), id, req.Lines)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": po})
+}
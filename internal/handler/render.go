@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"Bookstore/internal/sheet"
+	"Bookstore/internal/wrong"
+	"encoding/xml"
+	"github.com/gin-gonic/gin"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Форматы ответа эндпоинтов чтения
+const (
+	formatJSON = "json"
+	formatXML  = "xml"
+	formatCSV  = "csv"
+)
+
+// mediaTypes типы из Accept и их формат; при равных весах побеждает тот, что раньше в списке
+var mediaTypes = []struct{ mime, format string }{
+	{"application/json", formatJSON},
+	{"application/xml", formatXML},
+	{"text/xml", formatXML},
+	{"text/csv", formatCSV},
+}
+
+// payload тело ответа во всех форматах. JSON — {key: value}; XML — элемент root, у списка
+// каждый элемент в item; CSV — строки table(), первая — заголовок
+type payload struct {
+	key   string
+	value any
+	root  string
+	item  string
+	table func() [][]any
+}
+
+// negotiate формат по заголовку Accept с учётом q-весов; без заголовка — JSON.
+// ok=false — ни один формат не подходит, ответ 406
+func negotiate(c *gin.Context) (string, bool) {
+	accept := c.GetHeader("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON, true
+	}
+
+	type pref struct {
+		format string
+		q      float64
+		rank   int
+	}
+	var prefs []pref
+	for _, part := range strings.Split(accept, ",") {
+		mime, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mime = strings.ToLower(strings.TrimSpace(mime))
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		for rank, t := range mediaTypes {
+			if mediaMatches(mime, t.mime) {
+				prefs = append(prefs, pref{format: t.format, q: q, rank: rank})
+			}
+		}
+	}
+	if len(prefs) == 0 {
+		return "", false
+	}
+	sort.SliceStable(prefs, func(i, j int) bool {
+		if prefs[i].q != prefs[j].q {
+			return prefs[i].q > prefs[j].q
+		}
+		return prefs[i].rank < prefs[j].rank
+	})
+	return prefs[0].format, true
+}
+
+// mediaMatches range из Accept (*/*, text/*) или точный тип
+func mediaMatches(accepted, mime string) bool {
+	if accepted == "*/*" || accepted == mime {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(accepted, "/*")
+	return ok && strings.HasPrefix(mime, prefix+"/")
+}
+
+// render отдаёт тело в формате из Accept; неподдерживаемый Accept — 406
+func render(c *gin.Context, status int, body payload) {
+	format, ok := negotiate(c)
+	if !ok {
+		respondWithError(c, wrong.ErrNotAcceptable)
+		return
+	}
+	renderAs(c, format, status, body)
+}
+
+// renderAs для хендлеров, которым формат нужен раньше ответа (например, для ETag)
+func renderAs(c *gin.Context, format string, status int, body payload) {
+	c.Writer.Header().Add("Vary", "Accept")
+	switch format {
+	case formatXML:
+		c.XML(status, xmlPayload(body))
+	case formatCSV:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(status)
+		w, _ := sheet.NewWriter(sheet.CSV, c.Writer)
+		for _, record := range body.table() {
+			if err := w.Write(record); err != nil {
+				return
+			}
+		}
+		_ = w.Close()
+	default:
+		c.JSON(status, gin.H{body.key: body.value})
+	}
+}
+
+// formatETag ETag представления: разные форматы одной версии — разные представления
+func formatETag(etag, format string) string {
+	if format == formatJSON {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + format + `"`
+}
+
+type xmlPayload payload
+
+func (p xmlPayload) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	root := xml.StartElement{Name: xml.Name{Local: p.root}}
+	if p.item == "" {
+		return e.EncodeElement(p.value, root)
+	}
+	if err := e.EncodeToken(root); err != nil {
+		return err
+	}
+	items := reflect.ValueOf(p.value)
+	for i := 0; i < items.Len(); i++ {
+		if err := e.EncodeElement(items.Index(i).Interface(), xml.StartElement{Name: xml.Name{Local: p.item}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(root.End())
+}
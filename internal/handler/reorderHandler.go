@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultSalesWindowDays = 30
+	maxSalesWindowDays     = 365
+)
+
+type ReorderHandler struct {
+	service service.ReorderService
+}
+
+func NewReorderHandler(s service.ReorderService) *ReorderHandler {
+	return &ReorderHandler{service: s}
+}
+
+// Report ?days= — за сколько дней считать скорость продаж (по умолчанию 30, не больше 365)
+func (h *ReorderHandler) Report(c *gin.Context) {
+	days := defaultSalesWindowDays
+	if raw := c.Query("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || n <= 0:
+			respondWithError(c, wrong.Validation(wrong.FieldError{Field: "days", Code: "gt", Param: "0", Message: "days must be greater than 0"}))
+			return
+		case n > maxSalesWindowDays:
+			respondWithError(c, wrong.Validation(wrong.FieldError{Field: "days", Code: "max", Param: strconv.Itoa(maxSalesWindowDays),
+				Message: "days must be at most " + strconv.Itoa(maxSalesWindowDays)}))
+			return
+		}
+		days = n
+	}
+
+	suggestions, err := h.service.Report(c.Request.Context(), days)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type ReservationHandler struct {
+	service service.ReservationService
+}
+
+func NewReservationHandler(s service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{service: s}
+}
+
+// reservationRequest тело POST /reservations; срок резерва задаёт сервер (RESERVATION_TTL)
+type reservationRequest struct {
+	LocationID int                      `json:"location_id"`
+	Items      []models.ReservationLine `json:"items"`
+}
+
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	var req reservationRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	reservation := &models.Reservation{LocationID: req.LocationID, Items: req.Items}
+	if err := h.service.Reserve(c.Request.Context(), reservation); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": reservation})
+}
+
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidReservationID.Wrap(err))
+		return
+	}
+	reservation, err := h.service.GetReservation(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reservation})
+}
+
+// ReleaseReservation корзину очистили — товар сразу возвращается в продажу
+func (h *ReservationHandler) ReleaseReservation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidReservationID.Wrap(err))
+		return
+	}
+	if err := h.service.Release(c.Request.Context(), id); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
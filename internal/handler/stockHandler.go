@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+type StockHandler struct {
+	service service.StockService
+}
+
+func NewStockHandler(s service.StockService) *StockHandler {
+	return &StockHandler{service: s}
+}
+
+// stockAdjustmentRequest тело POST /admin/books/:id/stock-adjustments; автор берётся из токена,
+// без location_id движение относится к точке по умолчанию
+type stockAdjustmentRequest struct {
+	LocationID int    `json:"location_id"`
+	Kind       string `json:"kind"`
+	Delta      int    `json:"delta"`
+	Reason     string `json:"reason"`
+	Reference  string `json:"reference"`
+}
+
+func (h *StockHandler) CreateAdjustment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidBookID.Wrap(err))
+		return
+	}
+	var req stockAdjustmentRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	movement := &models.StockMovement{BookID: id, LocationID: req.LocationID, Kind: req.Kind, Delta: req.Delta, Reason: req.Reason, Reference: req.Reference}
+	if err := h.service.Adjust(c.Request.Context(), movement); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": movement})
+}
+
+// History ?limit= (по умолчанию 50, не больше 200) и ?before= — id записи из next_before предыдущей страницы
+func (h *StockHandler) History(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidBookID.Wrap(err))
+		return
+	}
+	limit, before, err := pageParams(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	movements, err := h.service.History(c.Request.Context(), id, before, limit)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	response := gin.H{"data": movements}
+	if len(movements) == limit {
+		response["next_before"] = movements[len(movements)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// pageParams limit и before из query строки с проверкой границ
+func pageParams(c *gin.Context) (int, int64, error) {
+	limit := defaultHistoryLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || n <= 0:
+			return 0, 0, wrong.Validation(wrong.FieldError{Field: "limit", Code: "gt", Param: "0", Message: "limit must be greater than 0"})
+		case n > maxHistoryLimit:
+			return 0, 0, wrong.Validation(wrong.FieldError{Field: "limit", Code: "max", Param: strconv.Itoa(maxHistoryLimit),
+				Message: "limit must be at most " + strconv.Itoa(maxHistoryLimit)})
+		}
+		limit = n
+	}
+
+	var before int64
+	if raw := c.Query("before"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, wrong.Validation(wrong.FieldError{Field: "before", Code: "integer", Message: "before must be a positive integer"})
+		}
+		before = n
+	}
+	return limit, before, nil
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type SupplierHandler struct {
+	service service.SupplierService
+}
+
+func NewSupplierHandler(s service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service: s}
+}
+
+func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
+	var supplier models.Supplier
+	if err := bindJSON(c, &supplier); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if err := h.service.CreateSupplier(c.Request.Context(), &supplier); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": supplier})
+}
+
+func (h *SupplierHandler) GetAllSuppliers(c *gin.Context) {
+	suppliers, err := h.service.GetAllSuppliers(c.Request.Context())
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": suppliers})
+}
+
+func (h *SupplierHandler) GetSupplierByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidSupplierID.Wrap(err))
+		return
+	}
+	supplier, err := h.service.GetSupplierByID(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": supplier})
+}
+
+func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidSupplierID.Wrap(err))
+		return
+	}
+	var supplier models.Supplier
+	if err := bindJSON(c, &supplier); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	supplier.ID = id
+	if err := h.service.UpdateSupplier(c.Request.Context(), &supplier); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": supplier})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/service"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type TransferHandler struct {
+	service service.TransferService
+}
+
+func NewTransferHandler(s service.TransferService) *TransferHandler {
+	return &TransferHandler{service: s}
+}
+
+// transferRequest тело POST /admin/transfers; автор берётся из токена
+type transferRequest struct {
+	BookID         int    `json:"book_id"`
+	FromLocationID int    `json:"from_location_id"`
+	ToLocationID   int    `json:"to_location_id"`
+	Quantity       int    `json:"quantity"`
+	Reference      string `json:"reference"`
+}
+
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	var req transferRequest
+	if err := bindJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	transfer := &models.Transfer{BookID: req.BookID, FromLocationID: req.FromLocationID, ToLocationID: req.ToLocationID,
+		Quantity: req.Quantity, Reference: req.Reference}
+	if err := h.service.CreateTransfer(c.Request.Context(), transfer); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": transfer})
+}
+
+func (h *TransferHandler) ReceiveTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidTransferID.Wrap(err))
+		return
+	}
+	transfer, err := h.service.ReceiveTransfer(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": transfer})
+}
+
+func (h *TransferHandler) GetTransferByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidTransferID.Wrap(err))
+		return
+	}
+	transfer, err := h.service.GetTransferByID(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": transfer})
+}
+
+// GetTransfers ?status=in_transit|received, постранично как история остатков (?limit=, ?before=)
+func (h *TransferHandler) GetTransfers(c *gin.Context) {
+	limit, before, err := pageParams(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	transfers, err := h.service.GetTransfers(c.Request.Context(), c.Query("status"), before, limit)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	response := gin.H{"data": transfers}
+	if len(transfers) == limit {
+		response["next_before"] = transfers[len(transfers)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
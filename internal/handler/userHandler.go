@@ -1,26 +1,28 @@
 package handler
 
 import (
+	"Bookstore/internal/i18n"
+	"Bookstore/internal/logging"
 	"Bookstore/internal/middleware"
 	"Bookstore/internal/models"
 	"Bookstore/internal/service"
 	"Bookstore/internal/wrong"
-	"errors"
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
-	"log" // Для логирования
+	"go.uber.org/zap"
 	"net/http"
 	"strconv"
 )
 
-// Хелпер для ответов с ошибкой
-func respondWithError(c *gin.Context, code int, message string) {
-	c.JSON(code, gin.H{"error": message})
+// Хелпер для ответов с ошибкой: ошибку отрисует middleware.Problems как application/problem+json
+func respondWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
 }
 
-// Хелпер для успешных ответов
-func respondWithSuccess(c *gin.Context, code int, message string) {
-	c.JSON(code, gin.H{"message": message})
+// Хелпер для успешных ответов: текст берётся из каталога на языке запроса
+func respondWithSuccess(c *gin.Context, code int, message wrong.MessageCode) {
+	c.JSON(code, gin.H{"code": message, "message": i18n.Message(i18n.FromContext(c.Request.Context()), message)})
 }
 
 type AuthHandler struct {
@@ -36,22 +38,21 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 
 func (h *AuthHandler) Register(c *gin.Context) {
 	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		log.Println("Error binding JSON in Register:", err) // Логируем ошибку привязки JSON
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+	if err := bindJSON(c, &user); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
-	//user.Role = "user" // по умолчанию роль обычного пользователя
-	err := h.AuthService.RegisterUser(&user)
+	// Через API регистрируются только обычные пользователи; админа назначает CLI (user create -admin, user set-role)
+	user.Role = models.RoleUser
+	err := h.AuthService.RegisterUser(c.Request.Context(), &user)
 	if err != nil {
-		log.Println("Error registering user:", err) // Логируем ошибку регистрации пользователя
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithError(c, err)
 		return
 	}
 
-	log.Println("User registered successfully:", user.Username) // Логируем успешную регистрацию
-	c.JSON(http.StatusOK, gin.H{"message": "User registered successfully"})
+	logging.FromContext(c.Request.Context()).Info("User registered successfully", zap.String("username", user.Username))
+	respondWithSuccess(c, http.StatusOK, wrong.MsgUserRegistered)
 }
 
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
@@ -59,9 +60,8 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		RefreshToken string `json:"refreshToken"`
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Println("Error binding JSON in RefreshToken:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+	if err := bindJSON(c, &input); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
@@ -71,15 +71,15 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	})
 
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid token or expired token"})
+		respondWithError(c, wrong.ErrInvalidJWT.Wrap(err))
 		return
 	}
 
-	username := claims["username"].(string)
-	role := claims["role"].(string)
+	username, _ := claims["username"].(string)
+	role, _ := claims["role"].(string)
 	newAccessToken, err := middleware.GenerateAccessToken(username, role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": newAccessToken})
@@ -87,82 +87,83 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var input models.User
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Println("Error binding JSON in Login:", err) // Логируем ошибку привязки JSON
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+	if err := bindJSON(c, &input); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
-	user, err := h.AuthService.Login(input.Username, input.Password)
+	user, err := h.AuthService.Login(c.Request.Context(), input.Username, input.Password)
 	if err != nil {
-		log.Println("Error during login for user:", input.Username, err) // Логируем ошибку при входе
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithError(c, err)
 		return
 	}
 
 	token, err := middleware.GenerateAccessToken(user.Username, user.Role)
 	if err != nil {
-		log.Println("Error generating JWT token for user:", user.Username, err) // Логируем ошибку генерации токена
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondWithError(c, err)
 		return
 	}
 
-	log.Println("User logged in successfully:", user.Username) // Логируем успешный вход
+	logging.FromContext(c.Request.Context()).Info("User logged in successfully", zap.String("username", user.Username))
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
 
 func (h *AuthHandler) GetAllUser(c *gin.Context) {
-	users, err := h.AuthService.GetAllUsers()
+	users, err := h.AuthService.GetAllUsers(c.Request.Context())
 	if err != nil {
-		log.Println("Error fetching all users:", err) // Логируем ошибку при получении всех пользователей
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithError(c, err)
 		return
 	}
 
-	log.Println("Successfully fetched all users") // Логируем успешное получение всех пользователей
-	c.JSON(http.StatusOK, gin.H{"users": users})
+	views := make([]*models.UserResponse, 0, len(users))
+	for _, user := range users {
+		views = append(views, user.Response())
+	}
+	render(c, http.StatusOK, payload{key: "users", value: views, root: "users", item: "user", table: userTable(views...)})
 }
 
 func (h *AuthHandler) GetUserByUsername(c *gin.Context) {
 	username := c.Param("username")
 
 	// Запрос на получение пользователя по имени
-	user, err := h.AuthService.GetUserByName(username)
+	user, err := h.AuthService.GetUserByName(c.Request.Context(), username)
 	if err != nil {
-		if errors.Is(err, wrong.ErrUserNotFound) {
-			log.Printf("User not found with username: %s", username)
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-		log.Printf("Error fetching user by username: %s, error: %v", username, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		respondWithError(c, err)
 		return
 	}
 
-	log.Printf("Successfully fetched user by username: %s", user.Username)
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	renderUser(c, user)
 }
 
 func (h *AuthHandler) GetUserByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		log.Println("Invalid user ID:", c.Param("id"), err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		respondWithError(c, wrong.ErrInvalidUserID.Wrap(err))
 		return
 	}
-	user, err := h.AuthService.GetByUserID(id)
+	user, err := h.AuthService.GetByUserID(c.Request.Context(), id)
 	if err != nil {
-		if errors.Is(err, wrong.ErrUserNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-		log.Printf("Error fetching user by id: %d, error: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		respondWithError(c, err)
 		return
 	}
 
-	log.Printf("Successfully fetched user by id: %d", id)
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	renderUser(c, user)
+}
+
+// renderUser один пользователь с ETag его версии; If-None-Match с той же версией — 304
+func renderUser(c *gin.Context, user *models.User) {
+	format, ok := negotiate(c)
+	if !ok {
+		respondWithError(c, wrong.ErrNotAcceptable)
+		return
+	}
+	etag := formatETag(userETag(user), format)
+	if notModified(c, etag) {
+		return
+	}
+	c.Header("ETag", etag)
+	view := user.Response()
+	renderAs(c, format, http.StatusOK, payload{key: "user", value: view, root: "user", table: userTable(view)})
 }
 
 func (h *AuthHandler) UpdateUser(c *gin.Context) {
@@ -170,56 +171,137 @@ func (h *AuthHandler) UpdateUser(c *gin.Context) {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		log.Printf("Invalid user id: %v", err)
-		c.JSON(400, gin.H{"error": "Invalid user id"})
+		respondWithError(c, wrong.ErrInvalidUserID.Wrap(err))
 		return
 	}
-	user.ID = id
 
 	// Привязка JSON данных к модели пользователя
-	if err := c.ShouldBindJSON(&user); err != nil {
-		log.Println("Error binding JSON in UpdateUser:", err) // Логируем ошибку
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+	if err := bindJSON(c, &user); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	user.ID = id
+	// Версию задаёт только If-Match, поле version в теле игнорируется
+	if user.Version, err = ifMatchVersion(c, wrong.ErrUserVersionMismatch); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
-	// Вызов метода обновления пользователя в AuthService
-	if err := h.AuthService.UpdateUser(&user); err != nil {
-		if err.Error() == "user not found" {
-			log.Printf("User not found for update, ID: %d", user.ID)
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-		log.Println("Error updating user:", user.ID, err) // Логируем ошибку
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	target, err := h.AuthService.GetByUserID(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if err := authorizeUserChange(c, target, user.Role != target.Role); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	// Вызов метода обновления пользователя в AuthService; 404 отдаёт middleware по wrong.ErrUserNotFound
+	if err := h.AuthService.UpdateUser(c.Request.Context(), &user); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("User updated successfully", zap.Int("id", user.ID))
+	c.Header("ETag", userETag(&user))
+	respondWithSuccess(c, http.StatusOK, wrong.MsgUserUpdated)
+}
+
+// PatchUser RFC 7396 merge patch: меняются только присланные поля
+func (h *AuthHandler) PatchUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, wrong.ErrInvalidUserID.Wrap(err))
+		return
+	}
+	version, err := ifMatchVersion(c, wrong.ErrUserVersionMismatch)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	patch, err := readMergePatch(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	target, err := h.AuthService.GetByUserID(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	// Невалидный JSON здесь пропускаем — его отклонит PatchUser
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(patch, &fields)
+	_, patchesRole := fields["role"]
+	if err := authorizeUserChange(c, target, patchesRole); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
-	log.Println("User updated successfully:", user.ID) // Логируем успешное обновление
-	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
+	user, err := h.AuthService.PatchUser(c.Request.Context(), id, version, patch)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.Header("ETag", userETag(user))
+	respondWithSuccess(c, http.StatusOK, wrong.MsgUserUpdated)
 }
 
 func (h *AuthHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		log.Println("Invalid user ID:", c.Param("id"), err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		respondWithError(c, wrong.ErrInvalidUserID.Wrap(err))
 		return
 	}
 
-	user, err := h.AuthService.GetByUserID(id)
+	version, err := ifMatchVersion(c, wrong.ErrUserVersionMismatch)
 	if err != nil {
-		log.Println("Error fetching user for deletion:", id, err) // Логируем ошибку при поиске пользователя для удаления
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		respondWithError(c, err)
 		return
 	}
 
-	if err := h.AuthService.DeleteUser(user); err != nil {
-		log.Println("Error deleting user:", user.Username, err) // Логируем ошибку при удалении пользователя
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	user, err := h.AuthService.GetByUserID(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if err := authorizeUserChange(c, user, false); err != nil {
+		respondWithError(c, err)
 		return
 	}
+	user.Version = version
+
+	if err := h.AuthService.DeleteUser(c.Request.Context(), user); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("User deleted successfully", zap.String("username", user.Username))
+	respondWithSuccess(c, http.StatusOK, wrong.MsgUserDeleted)
+}
+
+// authorizeUserChange админ меняет и удаляет любого пользователя; остальные — только себя и без смены роли
+func authorizeUserChange(c *gin.Context, target *models.User, roleChange bool) error {
+	if c.GetString("role") == models.RoleAdmin {
+		return nil
+	}
+	if target.Username != c.GetString("username") || roleChange {
+		logging.FromContext(c.Request.Context()).Warn("User change denied",
+			zap.Int("id", target.ID), zap.Bool("role_change", roleChange))
+		return wrong.ErrForbidden
+	}
+	return nil
+}
 
-	log.Println("User deleted successfully:", user.Username) // Логируем успешное удаление пользователя
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+// userTable CSV ответа с пользователями
+func userTable(users ...*models.UserResponse) func() [][]any {
+	return func() [][]any {
+		rows := [][]any{{"id", "username", "role"}}
+		for _, user := range users {
+			rows = append(rows, []any{user.ID, user.Username, user.Role})
+		}
+		return rows
+	}
 }
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DBPing проверяет, что пул соединений отвечает; задержку фиксирует Registry
+func DBPing(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// SchemaVersioner — то, что нужно от migrations.Migrator для проверки
+type SchemaVersioner interface {
+	Version(ctx context.Context) (int64, error)
+	Head() int64
+}
+
+// MigrationsAtHead проваливается, если схема базы отстаёт от встроенных миграций
+func MigrationsAtHead(m SchemaVersioner) Check {
+	return func(ctx context.Context) error {
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if head := m.Head(); version != head {
+			return fmt.Errorf("schema version %d, expected %d", version, head)
+		}
+		return nil
+	}
+}
+
+// Heartbeat отметка жизни фонового воркера
+type Heartbeat struct {
+	last atomic.Int64
+}
+
+func NewHeartbeat() *Heartbeat {
+	hb := &Heartbeat{}
+	hb.Beat()
+	return hb
+}
+
+// Beat воркер вызывает на каждой итерации
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+// Check проваливается, если воркер молчит дольше maxSilence
+func (h *Heartbeat) Check(maxSilence time.Duration) Check {
+	return func(ctx context.Context) error {
+		if silence := time.Since(h.Last()); silence > maxSilence {
+			return fmt.Errorf("no heartbeat for %s", silence.Round(time.Second))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,183 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check проверка одной зависимости; nil означает, что всё в порядке
+type Check func(ctx context.Context) error
+
+// State фаза жизненного цикла приложения, влияет на readiness
+type State int32
+
+const (
+	StateStarting State = iota
+	StateReady
+	StateStopping
+)
+
+func (s State) String() string {
+	switch s {
+	case StateReady:
+		return "ready"
+	case StateStopping:
+		return "stopping"
+	default:
+		return "starting"
+	}
+}
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+var errNotReady = errors.New("application is not ready")
+
+// CheckResult результат одной проверки в JSON ответе
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report тело ответа /healthz и /readyz
+type Report struct {
+	Status string                 `json:"status"`
+	State  string                 `json:"state"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Registry хранит проверки liveness и readiness, компоненты регистрируют их сами
+type Registry struct {
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+	state     atomic.Int32
+	timeout   time.Duration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{timeout: 2 * time.Second}
+}
+
+// AddLiveness проверка, при провале которой процесс нужно перезапустить
+func (r *Registry) AddLiveness(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, namedCheck{name: name, check: check})
+}
+
+// AddReadiness проверка, при провале которой на инстанс не нужно слать трафик
+func (r *Registry) AddReadiness(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, namedCheck{name: name, check: check})
+}
+
+func (r *Registry) SetState(state State) {
+	r.state.Store(int32(state))
+}
+
+func (r *Registry) State() State {
+	return State(r.state.Load())
+}
+
+// Live отчёт liveness; фаза приложения на него не влияет
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]namedCheck(nil), r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks, nil)
+}
+
+// Ready отчёт readiness; во время запуска и остановки всегда fail
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]namedCheck(nil), r.readiness...)
+	r.mu.RUnlock()
+
+	var gate error
+	if r.State() != StateReady {
+		gate = errNotReady
+	}
+	return r.run(ctx, checks, gate)
+}
+
+// run выполняет проверки параллельно, каждую со своим таймаутом
+func (r *Registry) run(ctx context.Context, checks []namedCheck, gate error) Report {
+	report := Report{
+		Status: StatusOK,
+		State:  r.State().String(),
+		Checks: make(map[string]CheckResult, len(checks)+1),
+	}
+	if gate != nil {
+		report.Status = StatusFail
+		report.Checks["lifecycle"] = CheckResult{Status: StatusFail, Error: gate.Error()}
+	}
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].name < checks[j].name })
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(checkCtx)
+			result := CheckResult{
+				Status:    StatusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(nc)
+	}
+	wg.Wait()
+	return report
+}
+
+// LiveHandler GET /healthz
+func (r *Registry) LiveHandler(c *gin.Context) {
+	respond(c, r.Live(c.Request.Context()))
+}
+
+// ReadyHandler GET /readyz
+func (r *Registry) ReadyHandler(c *gin.Context) {
+	respond(c, r.Ready(c.Request.Context()))
+}
+
+func respond(c *gin.Context, report Report) {
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
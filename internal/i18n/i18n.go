@@ -0,0 +1,217 @@
+package i18n
+
+import (
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Каталог сообщений: locales/<язык>.json, ключи вида
+//
+//	error.<код ошибки>        — detail для problem+json
+//	validation.<правило>      — сообщение ошибки поля, подстановки {field} и {param}
+//	message.<код сообщения>   — ответы об успехе
+//
+//go:embed locales/*.json
+var files embed.FS
+
+// Default язык по умолчанию и запасной вариант, если перевода нет
+const Default = "en"
+
+var catalog = load()
+
+func load() map[string]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	out := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", entry.Name(), err))
+		}
+		out[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return out
+}
+
+// Locales поддерживаемые языки в отсортированном виде
+func Locales() []string {
+	out := make([]string, 0, len(catalog))
+	for locale := range catalog {
+		out = append(out, locale)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Negotiate выбирает язык каталога по заголовку Accept-Language с учётом q-весов.
+// "ru-RU" совпадает с "ru"; если ничего не подошло — Default
+func Negotiate(acceptLanguage string) string {
+	for _, locale := range Preferences(acceptLanguage) {
+		if _, ok := catalog[locale]; ok {
+			return locale
+		}
+	}
+	return Default
+}
+
+// Preferences языки из Accept-Language по убыванию q без региона: "ru-RU,en;q=0.5" -> [ru en].
+// Нулевой вес и "*" пропускаются
+func Preferences(acceptLanguage string) []string {
+	type pref struct {
+		locale string
+		q      float64
+	}
+	var prefs []pref
+	seen := map[string]bool{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if base == "" || base == "*" || q <= 0 || seen[base] {
+			continue
+		}
+		seen[base] = true
+		prefs = append(prefs, pref{locale: base, q: q})
+	}
+
+	// Стабильная сортировка: при равных весах сохраняется порядок из заголовка
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	out := make([]string, len(prefs))
+	for i, p := range prefs {
+		out[i] = p.locale
+	}
+	return out
+}
+
+type localeKey struct{}
+
+// WithLocale кладёт выбранный язык в контекст запроса
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext язык запроса или Default
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return Default
+}
+
+// T перевод ключа с подстановкой параметров {name}; если ключа нет в языке —
+// берётся английский, если нет и там — сам ключ
+func T(locale, key string, params map[string]string) string {
+	text, ok := catalog[locale][key]
+	if !ok {
+		if text, ok = catalog[Default][key]; !ok {
+			return key
+		}
+	}
+	for name, value := range params {
+		text = strings.ReplaceAll(text, "{"+name+"}", value)
+	}
+	return text
+}
+
+// Message текст сообщения об успехе
+func Message(locale string, code wrong.MessageCode) string {
+	return T(locale, "message."+string(code), nil)
+}
+
+// Localize копия ошибки с переведёнными detail и сообщениями полей; код и статус не меняются
+func Localize(locale string, e *wrong.Error) *wrong.Error {
+	c := *e
+	if len(e.Fields) > 0 {
+		c.Fields = make([]wrong.FieldError, len(e.Fields))
+		for i, f := range e.Fields {
+			f.Message = field(locale, f)
+			c.Fields[i] = f
+		}
+	}
+
+	if len(c.Fields) == 1 {
+		c.Message = c.Fields[0].Message
+	} else {
+		c.Message = T(locale, "error."+string(e.Code), nil)
+	}
+	return &c
+}
+
+func field(locale string, f wrong.FieldError) string {
+	param := f.Param
+	if f.Code == "oneof" {
+		param = strings.ReplaceAll(param, " ", ", ")
+	}
+	return T(locale, "validation."+f.Code, map[string]string{"field": f.Field, "param": param})
+}
+
+// Keys ключи, которые обязан содержать каждый язык
+func Keys() []string {
+	var keys []string
+	for _, code := range wrong.Codes() {
+		keys = append(keys, "error."+string(code))
+	}
+	seen := map[string]bool{}
+	for _, rule := range append(wrong.Rules(), validation.Rules()...) {
+		if !seen[rule] {
+			seen[rule] = true
+			keys = append(keys, "validation."+rule)
+		}
+	}
+	for _, code := range wrong.Messages() {
+		keys = append(keys, "message."+string(code))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Check проверяет полноту каталога: в каждом языке есть все ключи и нет лишних
+func Check() error {
+	required := Keys()
+	want := make(map[string]bool, len(required))
+	for _, key := range required {
+		want[key] = true
+	}
+
+	var problems []string
+	for _, locale := range Locales() {
+		for _, key := range required {
+			if _, ok := catalog[locale][key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing %q", locale, key))
+			}
+		}
+		for key := range catalog[locale] {
+			if !want[key] {
+				problems = append(problems, fmt.Sprintf("%s: unknown key %q", locale, key))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("i18n catalog is incomplete:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package i18n
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestCatalogComplete в каждом встроенном языке есть все ключи и нет лишних
+func TestCatalogComplete(t *testing.T) {
+	for _, locale := range []string{"en", "ru", "tk"} {
+		if !slices.Contains(Locales(), locale) {
+			t.Errorf("locale %s is not embedded", locale)
+		}
+	}
+	if err := Check(); err != nil {
+		t.Fatal(err)
+	}
+}
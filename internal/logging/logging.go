@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type ctxKey struct{}
+
+// New создаёт базовый логгер приложения; уровень из LOG_LEVEL (debug, info, warn, error)
+// и делает его глобальным, чтобы FromContext без логгера в контексте не терял записи
+func New() (*zap.Logger, error) {
+	cfg := zap.NewProductionConfig()
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		parsed, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Level = zap.NewAtomicLevelAt(parsed)
+	}
+
+	logger, err := cfg.Build()
+	if err != nil {
+		return nil, err
+	}
+	zap.ReplaceGlobals(logger)
+	return logger, nil
+}
+
+// WithLogger кладёт логгер в контекст
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// With добавляет поля к логгеру из контекста (например, пользователя после авторизации)
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(fields...))
+}
+
+// FromContext логгер запроса с request_id; вне запроса (CLI, воркеры) — базовый логгер
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
@@ -0,0 +1,66 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// ContentType медиа тип JSON Merge Patch (RFC 7396)
+const ContentType = "application/merge-patch+json"
+
+// ErrNotObject патч ресурса должен быть JSON объектом
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply накладывает патч на документ по RFC 7396: null удаляет ключ, объекты сливаются
+// рекурсивно, всё остальное (в том числе массивы) заменяется целиком.
+// Возвращает новый документ и ключи верхнего уровня, которые затронул патч
+func Apply(doc, patch []byte) ([]byte, []string, error) {
+	var p map[string]any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, nil, ErrNotObject
+		}
+		return nil, nil, err
+	}
+	if p == nil {
+		return nil, nil, ErrNotObject
+	}
+
+	var d map[string]any
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+
+	out, err := json.Marshal(merge(d, p))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(keys)
+	return out, keys, nil
+}
+
+func merge(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = merge(t[key], value)
+	}
+	return t
+}
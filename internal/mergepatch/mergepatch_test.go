@@ -0,0 +1,66 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// TestApply примеры из приложения A RFC 7396, где патч — объект
+func TestApply(t *testing.T) {
+	for _, tc := range []struct {
+		doc, patch, want string
+		keys             []string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`, []string{"a"}},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`, []string{"b"}},
+		{`{"a":"b"}`, `{"a":null}`, `{}`, []string{"a"}},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`, []string{"a"}},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`, []string{"a"}},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`, []string{"a"}},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`, []string{"a"}},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`, []string{"a"}},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`, []string{"a"}},
+		{`{"a":"foo"}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`, []string{"a"}},
+		{`{"title":"T","price":1}`, `{"price":2,"author":"A"}`, `{"title":"T","price":2,"author":"A"}`, []string{"author", "price"}},
+		{`{"title":"T"}`, `{}`, `{"title":"T"}`, []string{}},
+	} {
+		got, keys, err := Apply([]byte(tc.doc), []byte(tc.patch))
+		if err != nil {
+			t.Errorf("Apply(%s, %s): %v", tc.doc, tc.patch, err)
+			continue
+		}
+		if !equalJSON(t, got, []byte(tc.want)) {
+			t.Errorf("Apply(%s, %s) = %s, want %s", tc.doc, tc.patch, got, tc.want)
+		}
+		if !slices.Equal(keys, tc.keys) {
+			t.Errorf("Apply(%s, %s) keys = %v, want %v", tc.doc, tc.patch, keys, tc.keys)
+		}
+	}
+}
+
+// TestApplyNotObject патч ресурса, который не объект, отклоняется
+func TestApplyNotObject(t *testing.T) {
+	for _, patch := range []string{`null`, `[{"a":1}]`, `"a"`, `1`} {
+		if _, _, err := Apply([]byte(`{"a":"b"}`), []byte(patch)); !errors.Is(err, ErrNotObject) {
+			t.Errorf("Apply(%s): err = %v, want ErrNotObject", patch, err)
+		}
+	}
+	if _, _, err := Apply([]byte(`{"a":"b"}`), []byte(`{"a":`)); err == nil || errors.Is(err, ErrNotObject) {
+		t.Errorf("malformed patch: err = %v, want a syntax error", err)
+	}
+}
+
+func equalJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var x, y any
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,49 @@
+package metrics
+
+// Namespace префикс всех метрик приложения
+const Namespace = "bookstore"
+
+// Counter монотонно растущий счётчик; значения labels передаются в порядке объявления
+type Counter interface {
+	Inc(labels ...string)
+	Add(value float64, labels ...string)
+}
+
+// Gauge значение, которое может расти и падать
+type Gauge interface {
+	Set(value float64, labels ...string)
+}
+
+// Histogram распределение наблюдений (задержки, размеры)
+type Histogram interface {
+	Observe(value float64, labels ...string)
+}
+
+// Registry — через него сервисы объявляют свои метрики, не завися от Prometheus.
+// Повторное объявление метрики с тем же именем возвращает уже существующую
+type Registry interface {
+	Counter(name, help string, labels ...string) Counter
+	Gauge(name, help string, labels ...string) Gauge
+	Histogram(name, help string, buckets []float64, labels ...string) Histogram
+}
+
+// Nop реестр, который ничего не собирает (CLI, тесты)
+func Nop() Registry {
+	return nop{}
+}
+
+type nop struct{}
+
+func (nop) Counter(string, string, ...string) Counter { return nop{} }
+
+func (nop) Gauge(string, string, ...string) Gauge { return nop{} }
+
+func (nop) Histogram(string, string, []float64, ...string) Histogram { return nop{} }
+
+func (nop) Inc(...string) {}
+
+func (nop) Add(float64, ...string) {}
+
+func (nop) Set(float64, ...string) {}
+
+func (nop) Observe(float64, ...string) {}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus реализация Registry поверх собственного prometheus.Registry
+type Prometheus struct {
+	reg *prometheus.Registry
+}
+
+// NewPrometheus создаёт реестр с метриками процесса и Go runtime
+func NewPrometheus() *Prometheus {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return &Prometheus{reg: reg}
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{Registry: p.reg})
+}
+
+// RegisterDBStats публикует статистику пула sql.DB (открытые, занятые, ожидания)
+func (p *Prometheus) RegisterDBStats(db *sql.DB, name string) {
+	register(p.reg, collectors.NewDBStatsCollector(db, name))
+}
+
+func (p *Prometheus) Counter(name, help string, labels ...string) Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+	}, labels)
+	return counter{register(p.reg, vec)}
+}
+
+func (p *Prometheus) Gauge(name, help string, labels ...string) Gauge {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+	}, labels)
+	return gauge{register(p.reg, vec)}
+}
+
+func (p *Prometheus) Histogram(name, help string, buckets []float64, labels ...string) Histogram {
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	}, labels)
+	return histogram{register(p.reg, vec)}
+}
+
+// register регистрирует коллектор или возвращает уже зарегистрированный с тем же именем
+func register[T prometheus.Collector](reg *prometheus.Registry, c T) T {
+	if err := reg.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+type counter struct{ vec *prometheus.CounterVec }
+
+func (c counter) Inc(labels ...string) { c.vec.WithLabelValues(labels...).Inc() }
+
+func (c counter) Add(value float64, labels ...string) { c.vec.WithLabelValues(labels...).Add(value) }
+
+type gauge struct{ vec *prometheus.GaugeVec }
+
+func (g gauge) Set(value float64, labels ...string) { g.vec.WithLabelValues(labels...).Set(value) }
+
+type histogram struct{ vec *prometheus.HistogramVec }
+
+func (h histogram) Observe(value float64, labels ...string) {
+	h.vec.WithLabelValues(labels...).Observe(value)
+}
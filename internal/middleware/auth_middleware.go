@@ -1,21 +1,33 @@
 package middleware
 
 import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/wrong"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"net/http"
 	"time"
 )
 
 // Используем безопасный генератор токенов
 var jwtKey = []byte("your_secret_key")
 
+// Claims токена доступа: имя пользователя в Subject и его роль
+type Claims struct {
+	Role string `json:"role"`
+	jwt.StandardClaims
+}
+
+// GenerateAccessToken роль попадает в токен, по ней AdminOnly пускает в админские маршруты
 func GenerateAccessToken(username, role string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		Subject:   username,
-		IssuedAt:  time.Now().Unix(),
-		ExpiresAt: time.Now().Add(time.Minute * 15).Unix(),
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Role: role,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   username,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(time.Minute * 15).Unix(),
+		},
 	})
 
 	tokenString, err := token.SignedString(jwtKey)
@@ -26,12 +38,13 @@ func GenerateAccessToken(username, role string) (string, error) {
 	return tokenString, nil
 }
 
+// AdminOnly пускает только роль admin из токена; ставится после AuthRequired
 func AdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists || role != "admin" {
-			zap.L().Warn("Unauthorized access attempt", zap.Any("role", role))
-			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have access to this resource"})
+			logging.FromContext(c.Request.Context()).Warn("Unauthorized access attempt", zap.Any("role", role))
+			_ = c.Error(wrong.ErrForbidden)
 			c.Abort()
 			return
 		}
@@ -43,25 +56,28 @@ func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			zap.L().Warn("Missing Authorization header")
-			c.JSON(http.StatusForbidden, gin.H{"error": "Authorization header required"})
+			logging.FromContext(c.Request.Context()).Warn("Missing Authorization header")
+			_ = c.Error(wrong.ErrUnauthorized)
 			c.Abort()
 			return
 		}
 
-		claims := &jwt.StandardClaims{}
+		claims := &Claims{}
 		tkn, err := jwt.ParseWithClaims(authHeader, claims, func(token *jwt.Token) (interface{}, error) {
 			return jwtKey, nil
 		})
 		if err != nil || !tkn.Valid {
-			zap.L().Error("Invalid token", zap.Error(err))
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid token"})
+			logging.FromContext(c.Request.Context()).Warn("Invalid token", zap.Error(err))
+			_ = c.Error(wrong.ErrInvalidJWT.Wrap(err))
 			c.Abort()
 			return
 		}
 
-		// Вытаскиваем данные пользователя
+		// Вытаскиваем данные пользователя; роль нужна AdminOnly
 		c.Set("username", claims.Subject)
+		c.Set("role", claims.Role)
+		ctx := actor.With(c.Request.Context(), claims.Subject)
+		c.Request = c.Request.WithContext(logging.With(ctx, zap.String("user", claims.Subject)))
 		c.Next()
 	}
 }
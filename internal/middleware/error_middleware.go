@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"Bookstore/internal/i18n"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/wrong"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// Problems отрисовывает последнюю ошибку из c.Errors как application/problem+json.
+// Хендлеры только вызывают c.Error(err) и выходят; внутренняя причина в ответ не попадает
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		RenderProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// Recovery превращает панику в 500 problem+json вместо пустого ответа. http.ErrAbortHandler
+// пропускается дальше: так хендлер, уже начавший ответ, просит сервер оборвать соединение
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		logging.FromContext(c.Request.Context()).Error("Panic recovered", zap.Any("panic", recovered), zap.Stack("stack"))
+		RenderProblem(c, wrong.ErrInternal)
+	})
+}
+
+// RenderProblem пишет ошибку в формате RFC 7807 на языке запроса и прерывает цепочку
+func RenderProblem(c *gin.Context, err error) {
+	e := i18n.Localize(i18n.FromContext(c.Request.Context()), wrong.From(err))
+	problem := e.Problem(c.Request.URL.Path)
+	problem.RequestID = c.GetString("request_id")
+
+	c.Header("Content-Type", wrong.ProblemContentType)
+	c.AbortWithStatusJSON(e.Status, problem)
+}
+
+// NoRoute 404 в том же формате для неизвестных маршрутов
+func NoRoute(c *gin.Context) {
+	_ = c.Error(wrong.ErrRouteNotFound)
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"Bookstore/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Locale выбирает язык ответа по Accept-Language и кладёт его в контекст запроса
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"Bookstore/internal/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestLogger берёт X-Request-ID клиента или генерирует новый, кладёт в контекст
+// логгер с request_id и trace_id и пишет одну строку на каждый запрос
+func RequestLogger(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Set("request_id", requestID)
+
+		fields := []zap.Field{zap.String("request_id", requestID)}
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			fields = append(fields, zap.String("trace_id", sc.TraceID().String()))
+		}
+		ctx := logging.WithLogger(c.Request.Context(), base.With(fields...))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		entry := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("route", route),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("user", c.GetString("username")),
+		}
+		if len(c.Errors) > 0 {
+			entry = append(entry, zap.String("errors", c.Errors.String()))
+		}
+
+		// Логгер из контекста запроса — на нём уже есть request_id и пользователь
+		logger := logging.FromContext(c.Request.Context())
+		switch {
+		case status >= 500:
+			logger.Error("HTTP request", entry...)
+		case status >= 400:
+			logger.Warn("HTTP request", entry...)
+		default:
+			logger.Info("HTTP request", entry...)
+		}
+	}
+}
+
+// validRequestID принимает только короткие печатные ID, чтобы не писать мусор в логи
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
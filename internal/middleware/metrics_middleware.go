@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"Bookstore/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// Metrics считает запросы и их длительность по шаблону маршрута gin (/books/:id),
+// а не по сырому пути, чтобы не плодить серии на каждый ID
+func Metrics(reg metrics.Registry) gin.HandlerFunc {
+	requests := reg.Counter("http_requests_total", "HTTP requests by route and status.",
+		"method", "route", "status")
+	duration := reg.Histogram("http_request_duration_seconds", "HTTP request latency by route.",
+		nil, "method", "route", "status")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requests.Inc(c.Request.Method, route, status)
+		duration.Observe(time.Since(start).Seconds(), c.Request.Method, route, status)
+	}
+}
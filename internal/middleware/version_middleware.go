@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"Bookstore/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deprecation когда версия API объявлена устаревшей и когда её отключат.
+// Successor — префикс версии-замены, например "/v1"
+type Deprecation struct {
+	Since     time.Time
+	Sunset    time.Time
+	Successor string
+}
+
+// APIVersion помечает запрос версией API и считает api_requests_total{version, deprecated}.
+// Для устаревших версий добавляет заголовки Deprecation (RFC 9745), Sunset (RFC 8594)
+// и Link на тот же маршрут в версии-замене
+func APIVersion(reg metrics.Registry, version, prefix string, dep *Deprecation) gin.HandlerFunc {
+	requests := reg.Counter("api_requests_total", "Requests by API version.", "version", "deprecated")
+	deprecated := strconv.FormatBool(dep != nil)
+
+	return func(c *gin.Context) {
+		c.Set("api_version", version)
+		requests.Inc(version, deprecated)
+
+		if dep != nil {
+			c.Header("Deprecation", "@"+strconv.FormatInt(dep.Since.Unix(), 10))
+			if !dep.Sunset.IsZero() {
+				c.Header("Sunset", dep.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if dep.Successor != "" {
+				successor := dep.Successor + strings.TrimPrefix(c.Request.URL.Path, prefix)
+				c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+			}
+		}
+		c.Next()
+	}
+}
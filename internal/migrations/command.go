@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const usage = "usage: migrate up | down [steps] | status | goto <version>"
+
+// RunCommand выполняет подкоманду migrate: up, down, status или goto
+func RunCommand(ctx context.Context, m *Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid steps: %s", args[1])
+			}
+			steps = n
+		}
+		if err := m.Down(ctx, steps); err != nil {
+			return err
+		}
+	case "goto":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		if err := m.Goto(ctx, version); err != nil {
+			return err
+		}
+	case "status":
+		return printStatus(ctx, m, out)
+	default:
+		return errors.New(usage)
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "schema version: %d (head %d)\n", version, m.Head())
+	return nil
+}
+
+func printStatus(ctx context.Context, m *Migrator, out io.Writer) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		applied := "pending"
+		if s.Applied {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(out, "%04d  %-30s  %s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
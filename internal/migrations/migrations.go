@@ -0,0 +1,334 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey — ключ advisory lock, чтобы несколько экземпляров не мигрировали одновременно
+const lockKey int64 = 7_301_926_026
+
+const (
+	queryCreateTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`
+	queryApplied       = "SELECT version, applied_at FROM schema_migrations ORDER BY version"
+	queryInsertVersion = "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)"
+	queryDeleteVersion = "DELETE FROM schema_migrations WHERE version = $1"
+	queryLock          = "SELECT pg_advisory_lock($1)"
+	queryUnlock        = "SELECT pg_advisory_unlock($1)"
+)
+
+var ErrUnknownVersion = errors.New("unknown migration version")
+
+// Migration одна версия схемы: SQL для применения и отката
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status состояние миграции в базе данных
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	Log        *zap.Logger
+	migrations []Migration
+}
+
+// New загружает встроенные SQL файлы и создаёт Migrator
+func New(db *sql.DB, logger *zap.Logger) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, Log: logger, migrations: migrations}, nil
+}
+
+// load читает файлы вида 0001_name.up.sql / 0001_name.down.sql
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", name, err)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join("sql", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", name, err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Head последняя известная версия схемы
+func (m *Migrator) Head() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version текущая версия схемы в базе данных
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status возвращает состояние каждой известной миграции
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		at, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: at,
+		})
+	}
+	return statuses, nil
+}
+
+// Up применяет все неприменённые миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Goto(ctx, m.Head())
+}
+
+// Down откатывает последние steps миграций
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// Goto приводит схему к указанной версии, применяя или откатывая миграции
+func (m *Migrator) Goto(ctx context.Context, version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// Сначала откатываем всё, что выше целевой версии
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// apply выполняет одну миграцию и запись в schema_migrations в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	body, direction := migration.Up, "up"
+	if !up {
+		body, direction = migration.Down, "down"
+		if body == "" {
+			return fmt.Errorf("migration %d has no down file", migration.Version)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %v", migration.Version, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		m.Log.Error("Migration failed", zap.Int64("version", migration.Version),
+			zap.String("direction", direction), zap.Error(err))
+		return fmt.Errorf("migration %d %s failed: %v", migration.Version, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, queryInsertVersion, migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, queryDeleteVersion, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %v", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %v", migration.Version, err)
+	}
+	m.Log.Info("Migration applied", zap.Int64("version", migration.Version),
+		zap.String("name", migration.Name), zap.String("direction", direction))
+	return nil
+}
+
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// applied возвращает применённые версии и время их применения
+func (m *Migrator) applied(ctx context.Context, q queryer) (map[int64]time.Time, error) {
+	if _, err := q.ExecContext(ctx, queryCreateTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	rows, err := q.QueryContext(ctx, queryApplied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// withLock держит advisory lock на отдельном соединении, пока выполняется fn
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %v", err)
+	}
+	defer func(conn *sql.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	if _, err := conn.ExecContext(ctx, queryLock, lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+	defer func() {
+		// Разблокируем даже если ctx уже отменён
+		if _, err := conn.ExecContext(context.Background(), queryUnlock, lockKey); err != nil {
+			m.Log.Error("Failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	return fn(conn)
+}
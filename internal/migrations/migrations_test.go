@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestLoad up и down одной версии собираются в одну миграцию, миграции идут по возрастанию версии
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0002_create_books.up.sql":   {Data: []byte("CREATE TABLE books ();")},
+		"sql/0002_create_books.down.sql": {Data: []byte("DROP TABLE books;")},
+		"sql/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"sql/README.md":                  {Data: []byte("not a migration")},
+	}
+	got, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users ();"},
+		{Version: 2, Name: "create_books", Up: "CREATE TABLE books ();", Down: "DROP TABLE books;"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d migrations, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestLoadInvalid ошибки в именах файлов и миграция без up не загружаются
+func TestLoadInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name, file, want string
+	}{
+		{"no name", "sql/0001.up.sql", "invalid migration file name"},
+		{"bad version", "sql/first_create_users.up.sql", "invalid migration version"},
+		{"down only", "sql/0001_create_users.down.sql", "has no up file"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := load(fstest.MapFS{tc.file: {Data: []byte("SELECT 1;")}})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestEmbedded встроенные миграции загружаются, версии идут подряд с 1 и у каждой есть откат
+func TestEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", m.Name, m.Version, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS books (
+    id       INTEGER PRIMARY KEY,
+    title    VARCHAR(255)   NOT NULL,
+    author   VARCHAR(255)   NOT NULL,
+    price    NUMERIC(10, 2) NOT NULL,
+    quantity INTEGER        NOT NULL DEFAULT 0
+);
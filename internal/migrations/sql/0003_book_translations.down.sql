@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS book_translations;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS translation_of,
+    DROP COLUMN IF EXISTS original_language;
@@ -0,0 +1,26 @@
+-- Язык оригинала и связь перевода с изданием, с которого он сделан
+ALTER TABLE books
+    ADD COLUMN original_language VARCHAR(8) NOT NULL DEFAULT 'en',
+    ADD COLUMN translation_of    INTEGER REFERENCES books (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS books_translation_of_idx ON books (translation_of);
+
+-- Название, подзаголовок и описание на каждом языке; search — индекс поиска по всем языкам
+CREATE TABLE IF NOT EXISTS book_translations (
+    book_id     INTEGER      NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    locale      VARCHAR(8)   NOT NULL,
+    title       VARCHAR(255) NOT NULL,
+    subtitle    VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT         NOT NULL DEFAULT '',
+    search      TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', title || ' ' || subtitle || ' ' || description)
+    ) STORED,
+    PRIMARY KEY (book_id, locale)
+);
+
+CREATE INDEX IF NOT EXISTS book_translations_search_idx ON book_translations USING GIN (search);
+
+-- Существующие книги получают перевод на языке оригинала из books.title
+INSERT INTO book_translations (book_id, locale, title)
+SELECT id, original_language, title FROM books
+ON CONFLICT DO NOTHING;
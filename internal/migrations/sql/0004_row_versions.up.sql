@@ -0,0 +1,3 @@
+-- Версия строки для оптимистической блокировки: ETag и If-Match
+ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_quantity_non_negative;
+DROP TABLE IF EXISTS stock_movements;
+DROP FUNCTION IF EXISTS stock_movements_append_only();
@@ -0,0 +1,36 @@
+-- Журнал движения товара: только добавление; books.quantity — остаток после последней записи
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id         BIGSERIAL PRIMARY KEY,
+    book_id    INTEGER      NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    kind       VARCHAR(16)  NOT NULL CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment', 'damage')),
+    delta      INTEGER      NOT NULL CHECK (delta <> 0),
+    balance    INTEGER      NOT NULL CHECK (balance >= 0),
+    reason     TEXT         NOT NULL DEFAULT '',
+    actor      VARCHAR(255) NOT NULL,
+    reference  VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_book_idx ON stock_movements (book_id, id DESC);
+
+-- Записи журнала не меняются и не удаляются; исключение — каскадное удаление вместе с книгой
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+ALTER TABLE books ADD CONSTRAINT books_quantity_non_negative CHECK (quantity >= 0);
+
+-- Начальные остатки, чтобы сумма движений сходилась с books.quantity
+INSERT INTO stock_movements (book_id, kind, delta, balance, reason, actor)
+SELECT id, 'adjustment', quantity, quantity, 'opening balance', 'system'
+FROM books
+WHERE quantity > 0;
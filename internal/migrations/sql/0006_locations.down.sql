@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS order_lines;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS transfers;
+
+ALTER TABLE stock_movements DISABLE TRIGGER stock_movements_append_only;
+DELETE FROM stock_movements WHERE kind IN ('transfer_out', 'transfer_in');
+ALTER TABLE stock_movements ENABLE TRIGGER stock_movements_append_only;
+
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_kind_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_kind_check
+    CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment', 'damage'));
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS location_id;
+
+DROP TABLE IF EXISTS stock_levels;
+DROP TABLE IF EXISTS locations;
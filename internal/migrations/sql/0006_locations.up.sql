@@ -0,0 +1,75 @@
+-- Магазины и склады; на складе по умолчанию лежит остаток, который меняют PUT/PATCH книги
+CREATE TABLE IF NOT EXISTS locations (
+    id         SERIAL PRIMARY KEY,
+    code       VARCHAR(32)  NOT NULL UNIQUE,
+    name       VARCHAR(255) NOT NULL,
+    kind       VARCHAR(16)  NOT NULL CHECK (kind IN ('store', 'warehouse')),
+    latitude   DOUBLE PRECISION,
+    longitude  DOUBLE PRECISION,
+    is_default BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS locations_one_default_idx ON locations (is_default) WHERE is_default;
+
+INSERT INTO locations (code, name, kind, is_default) VALUES ('main', 'Main warehouse', 'warehouse', TRUE);
+
+-- Остаток по точкам; books.quantity — их сумма
+CREATE TABLE IF NOT EXISTS stock_levels (
+    book_id     INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations (id),
+    quantity    INTEGER NOT NULL CHECK (quantity >= 0),
+    PRIMARY KEY (book_id, location_id)
+);
+
+INSERT INTO stock_levels (book_id, location_id, quantity)
+SELECT id, (SELECT id FROM locations WHERE code = 'main'), quantity FROM books WHERE quantity > 0;
+
+-- Движения теперь привязаны к точке; balance — остаток в этой точке после движения
+ALTER TABLE stock_movements ADD COLUMN location_id INTEGER REFERENCES locations (id);
+
+ALTER TABLE stock_movements DISABLE TRIGGER stock_movements_append_only;
+UPDATE stock_movements SET location_id = (SELECT id FROM locations WHERE code = 'main');
+ALTER TABLE stock_movements ENABLE TRIGGER stock_movements_append_only;
+
+ALTER TABLE stock_movements ALTER COLUMN location_id SET NOT NULL;
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_kind_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_kind_check
+    CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment', 'damage', 'transfer_out', 'transfer_in'));
+
+-- Перемещение между точками: при отправке товар списывается, при приёмке приходуется
+CREATE TABLE IF NOT EXISTS transfers (
+    id               SERIAL PRIMARY KEY,
+    book_id          INTEGER      NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    from_location_id INTEGER      NOT NULL REFERENCES locations (id),
+    to_location_id   INTEGER      NOT NULL REFERENCES locations (id),
+    quantity         INTEGER      NOT NULL CHECK (quantity > 0),
+    status           VARCHAR(16)  NOT NULL DEFAULT 'in_transit' CHECK (status IN ('in_transit', 'received')),
+    reference        VARCHAR(255) NOT NULL DEFAULT '',
+    created_by       VARCHAR(255) NOT NULL,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    received_by      VARCHAR(255),
+    received_at      TIMESTAMPTZ,
+    CHECK (from_location_id <> to_location_id)
+);
+
+CREATE INDEX IF NOT EXISTS transfers_status_idx ON transfers (status, id);
+
+-- Заказы: товар списывается с выбранной точки при оформлении
+CREATE TABLE IF NOT EXISTS orders (
+    id          SERIAL PRIMARY KEY,
+    location_id INTEGER        NOT NULL REFERENCES locations (id),
+    status      VARCHAR(16)    NOT NULL DEFAULT 'placed',
+    total       NUMERIC(12, 2) NOT NULL,
+    created_by  VARCHAR(255)   NOT NULL,
+    created_at  TIMESTAMPTZ    NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS order_lines (
+    order_id INTEGER        NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    line     INTEGER        NOT NULL,
+    book_id  INTEGER        NOT NULL REFERENCES books (id),
+    quantity INTEGER        NOT NULL CHECK (quantity > 0),
+    price    NUMERIC(10, 2) NOT NULL,
+    PRIMARY KEY (order_id, line)
+);
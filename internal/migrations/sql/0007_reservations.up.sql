@@ -0,0 +1,21 @@
+-- Резерв держит товар в точке до expires_at; доступно к продаже = остаток минус активные резервы
+CREATE TABLE IF NOT EXISTS reservations (
+    id          SERIAL PRIMARY KEY,
+    location_id INTEGER      NOT NULL REFERENCES locations (id),
+    status      VARCHAR(16)  NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'released', 'expired', 'converted')),
+    order_id    INTEGER REFERENCES orders (id),
+    created_by  VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ  NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reservation_lines (
+    reservation_id INTEGER NOT NULL REFERENCES reservations (id) ON DELETE CASCADE,
+    book_id        INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    quantity       INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (reservation_id, book_id)
+);
+
+-- Частичные индексы: сборщик и подсчёт доступного смотрят только на активные резервы
+CREATE INDEX IF NOT EXISTS reservations_active_expires_idx ON reservations (expires_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS reservation_lines_book_idx ON reservation_lines (book_id);
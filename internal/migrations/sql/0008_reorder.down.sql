@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS stock_movements_kind_created_idx;
+DROP TABLE IF EXISTS reorder_alerts;
+ALTER TABLE books DROP COLUMN IF EXISTS reorder_quantity;
+ALTER TABLE books DROP COLUMN IF EXISTS reorder_point;
@@ -0,0 +1,15 @@
+-- Точка заказа: остаток на уровне reorder_point или ниже — пора заказывать (0 — не следим).
+-- reorder_quantity — минимальная партия заказа
+ALTER TABLE books ADD COLUMN reorder_point INTEGER NOT NULL DEFAULT 0 CHECK (reorder_point >= 0);
+ALTER TABLE books ADD COLUMN reorder_quantity INTEGER NOT NULL DEFAULT 0 CHECK (reorder_quantity >= 0);
+
+-- Книги, о нехватке которых уже предупредили; строка удаляется, когда остаток снова выше точки заказа,
+-- чтобы следующее падение снова дало алерт
+CREATE TABLE IF NOT EXISTS reorder_alerts (
+    book_id    INTEGER     PRIMARY KEY REFERENCES books (id) ON DELETE CASCADE,
+    quantity   INTEGER     NOT NULL,
+    alerted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Скорость продаж считается по журналу за последние дни
+CREATE INDEX IF NOT EXISTS stock_movements_kind_created_idx ON stock_movements (kind, created_at);
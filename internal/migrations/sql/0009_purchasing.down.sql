@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+ALTER TABLE books DROP COLUMN IF EXISTS cost_price;
+DROP TABLE IF EXISTS suppliers;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS suppliers (
+    id             SERIAL PRIMARY KEY,
+    name           VARCHAR(255) NOT NULL UNIQUE,
+    contact_name   VARCHAR(255) NOT NULL DEFAULT '',
+    email          VARCHAR(255) NOT NULL DEFAULT '',
+    phone          VARCHAR(50)  NOT NULL DEFAULT '',
+    lead_time_days INTEGER      NOT NULL DEFAULT 0 CHECK (lead_time_days >= 0),
+    payment_terms  VARCHAR(255) NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+-- Закупочная цена: средневзвешенная по приходам от поставщиков, NULL — ещё не закупали
+ALTER TABLE books ADD COLUMN cost_price NUMERIC(10, 2) CHECK (cost_price >= 0);
+
+-- Заказ поставщику: draft -> sent -> partially_received -> received; товар приходуется в location_id
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id          SERIAL PRIMARY KEY,
+    supplier_id INTEGER      NOT NULL REFERENCES suppliers (id),
+    location_id INTEGER      NOT NULL REFERENCES locations (id),
+    status      VARCHAR(20)  NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'sent', 'partially_received', 'received')),
+    notes       VARCHAR(1000) NOT NULL DEFAULT '',
+    created_by  VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    sent_at     TIMESTAMPTZ,
+    expected_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS purchase_orders_status_idx ON purchase_orders (status, id);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    order_id          INTEGER        NOT NULL REFERENCES purchase_orders (id) ON DELETE CASCADE,
+    line              INTEGER        NOT NULL,
+    book_id           INTEGER        NOT NULL REFERENCES books (id),
+    quantity          INTEGER        NOT NULL CHECK (quantity > 0),
+    received_quantity INTEGER        NOT NULL DEFAULT 0 CHECK (received_quantity >= 0 AND received_quantity <= quantity),
+    unit_cost         NUMERIC(10, 2) NOT NULL CHECK (unit_cost >= 0),
+    PRIMARY KEY (order_id, line)
+);
+
+CREATE INDEX IF NOT EXISTS purchase_order_lines_book_idx ON purchase_order_lines (book_id);
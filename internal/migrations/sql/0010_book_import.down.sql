@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS import_jobs;
+ALTER TABLE books DROP COLUMN IF EXISTS isbn;
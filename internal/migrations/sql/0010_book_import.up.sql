@@ -0,0 +1,27 @@
+-- ISBN-13 без дефисов; NULL — не указан (уникальность проверяется только среди заполненных)
+ALTER TABLE books ADD COLUMN isbn VARCHAR(13) UNIQUE;
+
+-- Фоновый импорт каталога из CSV/XLSX. data — сам файл, очищается после обработки;
+-- processed_rows позволяет продолжить задачу, если воркер остановился посреди файла
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id             SERIAL PRIMARY KEY,
+    status         VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    format         VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'xlsx')),
+    match          VARCHAR(10) NOT NULL CHECK (match IN ('isbn', 'id')),
+    dry_run        BOOLEAN     NOT NULL DEFAULT false,
+    mapping        JSONB       NOT NULL DEFAULT '{}',
+    data           BYTEA,
+    total_rows     INTEGER     NOT NULL DEFAULT 0,
+    processed_rows INTEGER     NOT NULL DEFAULT 0,
+    created_count  INTEGER     NOT NULL DEFAULT 0,
+    updated_count  INTEGER     NOT NULL DEFAULT 0,
+    failed_count   INTEGER     NOT NULL DEFAULT 0,
+    errors         JSONB       NOT NULL DEFAULT '[]',
+    error          TEXT        NOT NULL DEFAULT '',
+    created_by     VARCHAR(255) NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS import_jobs_status_idx ON import_jobs (status, id);
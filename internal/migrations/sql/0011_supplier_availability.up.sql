@@ -0,0 +1,3 @@
+-- Остаток у издателя по последнему ONIX-фиду: Stock/OnHand, 0 — товар у издателя недоступен; NULL — фид не сообщал.
+-- Свой остаток books.quantity фид не трогает, он меняется только журналом движений
+ALTER TABLE books ADD COLUMN supplier_availability INTEGER CHECK (supplier_availability >= 0);
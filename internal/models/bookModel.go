@@ -1,9 +1,131 @@
 package models
 
+import "strings"
+
+// Book теги validate — правила, которые проверяет сервис (см. пакет validation).
+// Title — название на языке оригинала; Subtitle, Description и Language заполняются
+// из перевода, выбранного по Accept-Language (см. Localize)
 type Book struct {
-	ID       int     `json:"id"`
-	Title    string  `json:"title"`
-	Author   string  `json:"author"`
-	Price    float64 `json:"price"`
-	Quantity int     `json:"quantity"`
+	ID               int               `json:"id" xml:"id" validate:"gt=0"`
+	ISBN             string            `json:"isbn,omitempty" xml:"isbn,omitempty" validate:"omitempty,isbn"`
+	Title            string            `json:"title" xml:"title" validate:"required,max=255"`
+	Subtitle         string            `json:"subtitle,omitempty" xml:"subtitle,omitempty"`
+	Description      string            `json:"description,omitempty" xml:"description,omitempty"`
+	Language         string            `json:"language,omitempty" xml:"language,omitempty"`
+	Author           string            `json:"author" xml:"author" validate:"required,max=255"`
+	Price            float64           `json:"price" xml:"price" validate:"price,lte=1000000"`
+	Quantity         int               `json:"quantity" xml:"quantity" validate:"gte=0,lte=1000000"`
+	OriginalLanguage string            `json:"original_language,omitempty" xml:"original_language,omitempty" validate:"omitempty,language"`
+	TranslationOf    *int              `json:"translation_of,omitempty" xml:"translation_of,omitempty" validate:"omitempty,gt=0"`
+	Translations     []BookTranslation `json:"translations,omitempty" xml:"translations>translation,omitempty" validate:"omitempty,dive"`
+	Languages        []string          `json:"languages,omitempty" xml:"languages>language,omitempty"`
+	// ReorderPoint остаток, на котором пора заказывать (0 — не следим); ReorderQuantity — минимальная партия
+	ReorderPoint    int `json:"reorder_point" xml:"reorder_point" validate:"gte=0,lte=1000000"`
+	ReorderQuantity int `json:"reorder_quantity" xml:"reorder_quantity" validate:"gte=0,lte=1000000"`
+	// CostPrice средняя закупочная цена, пересчитывается при приёмке заказа поставщику; nil — закупок не было
+	CostPrice *float64 `json:"cost_price,omitempty" xml:"cost_price,omitempty"`
+	// SupplierAvailability остаток у издателя по последнему ONIX-фиду (0 — недоступен); nil — фид не сообщал
+	SupplierAvailability *int `json:"supplier_availability,omitempty" xml:"supplier_availability,omitempty"`
+	// Available остаток минус активные резервы; заполняется при чтении книги и списка
+	Available *int `json:"available,omitempty" xml:"available,omitempty"`
+	// Version растёт при каждом изменении книги через каталог; движения склада её не меняют. Клиенту приходит как ETag
+	Version int `json:"version" xml:"version"`
+}
+
+// BookTranslation метаданные книги на одном языке
+type BookTranslation struct {
+	Locale      string `json:"locale" xml:"locale" validate:"required,language"`
+	Title       string `json:"title" xml:"title" validate:"required,max=255"`
+	Subtitle    string `json:"subtitle,omitempty" xml:"subtitle,omitempty" validate:"max=255"`
+	Description string `json:"description,omitempty" xml:"description,omitempty" validate:"max=10000"`
+}
+
+// NormalizeISBN убирает дефисы и пробелы и переводит ISBN-10 в ISBN-13; строку,
+// которая не похожа на ISBN-10, возвращает без изменений — её отклонит правило isbn
+func NormalizeISBN(isbn string) string {
+	isbn = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+	if len(isbn) != 10 {
+		return isbn
+	}
+	sum := 0
+	for i, ch := range isbn {
+		switch {
+		case ch >= '0' && ch <= '9':
+			sum += (10 - i) * int(ch-'0')
+		case ch == 'X' && i == 9:
+			sum += 10
+		default:
+			return isbn
+		}
+	}
+	if sum%11 != 0 {
+		return isbn
+	}
+
+	digits := "978" + isbn[:9]
+	return digits + string(rune('0'+isbn13Check(digits)))
+}
+
+// ValidISBN13 13 цифр с префиксом 978 или 979 и верной контрольной цифрой
+func ValidISBN13(isbn string) bool {
+	if len(isbn) != 13 || !(strings.HasPrefix(isbn, "978") || strings.HasPrefix(isbn, "979")) {
+		return false
+	}
+	for _, ch := range isbn {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return isbn13Check(isbn[:12]) == int(isbn[12]-'0')
+}
+
+// isbn13Check контрольная цифра по первым 12 цифрам
+func isbn13Check(digits string) int {
+	sum := 0
+	for i, ch := range digits[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(ch-'0')
+	}
+	return (10 - sum%10) % 10
+}
+
+// DefaultBookLanguage язык оригинала, если он не указан
+const DefaultBookLanguage = "en"
+
+// Translation перевод на языке locale, если он есть
+func (b *Book) Translation(locale string) (BookTranslation, bool) {
+	for _, t := range b.Translations {
+		if t.Locale == locale {
+			return t, true
+		}
+	}
+	return BookTranslation{}, false
+}
+
+// Localize подставляет метаданные первого языка из prefs, на который есть перевод;
+// если подходящего нет — язык оригинала. Вместо Translations в ответе остаётся список языков
+func (b *Book) Localize(prefs []string) {
+	chosen, ok := BookTranslation{}, false
+	for _, locale := range prefs {
+		if chosen, ok = b.Translation(locale); ok {
+			break
+		}
+	}
+	if !ok {
+		chosen, ok = b.Translation(b.OriginalLanguage)
+	}
+
+	if ok {
+		b.Title, b.Subtitle, b.Description, b.Language = chosen.Title, chosen.Subtitle, chosen.Description, chosen.Locale
+	} else {
+		b.Language = b.OriginalLanguage
+	}
+	b.Languages = make([]string, 0, len(b.Translations))
+	for _, t := range b.Translations {
+		b.Languages = append(b.Languages, t.Locale)
+	}
+	b.Translations = nil
 }
@@ -0,0 +1,45 @@
+package models
+
+import "testing"
+
+// TestNormalizeISBN дефисы и пробелы убираются, верный ISBN-10 становится ISBN-13, остальное не меняется
+func TestNormalizeISBN(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"978-0-306-40615-7", "9780306406157"},
+		{" 978 0 306 40615 7 ", "9780306406157"},
+		{"0-306-40615-2", "9780306406157"},
+		{"043942089x", "9780439420891"},
+		{"043942089X", "9780439420891"},
+		{"0306406153", "0306406153"},
+		{"03064061X2", "03064061X2"},
+		{"not an isbn", "NOTANISBN"},
+		{"", ""},
+	} {
+		if got := NormalizeISBN(tc.in); got != tc.want {
+			t.Errorf("NormalizeISBN(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestValidISBN13 префикс 978 или 979, только цифры и верная контрольная цифра
+func TestValidISBN13(t *testing.T) {
+	for _, tc := range []struct {
+		isbn string
+		want bool
+	}{
+		{"9780306406157", true},
+		{"9780439420891", true},
+		{"9791000000008", true},
+		{"9780306406158", false},
+		{"9770306406157", false},
+		{"978030640615", false},
+		{"97803064061570", false},
+		{"978030640615X", false},
+		{"978-0306406157", false},
+		{"", false},
+	} {
+		if got := ValidISBN13(tc.isbn); got != tc.want {
+			t.Errorf("ValidISBN13(%q) = %v, want %v", tc.isbn, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"Bookstore/internal/wrong"
+	"time"
+)
+
+// Статусы задачи импорта
+const (
+	ImportQueued  = "queued"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// По какому полю строка файла ищет существующую книгу
+const (
+	ImportMatchISBN = "isbn"
+	ImportMatchID   = "id"
+)
+
+// ImportJob импорт книг из файла. Mapping — поле книги -> заголовок колонки в файле;
+// поля без сопоставления ищутся по колонке с тем же именем. Маленькие файлы обрабатываются
+// сразу в запросе (ID = 0), большие — воркером, клиент опрашивает задачу по ID
+type ImportJob struct {
+	ID            int               `json:"id,omitempty"`
+	Status        string            `json:"status"`
+	Format        string            `json:"format" validate:"oneof=csv xlsx"`
+	Match         string            `json:"match" validate:"oneof=isbn id"`
+	DryRun        bool              `json:"dry_run"`
+	Mapping       map[string]string `json:"mapping,omitempty"`
+	TotalRows     int               `json:"total_rows"`
+	ProcessedRows int               `json:"processed_rows"`
+	Created       int               `json:"created"`
+	Updated       int               `json:"updated"`
+	Failed        int               `json:"failed"`
+	Errors        []ImportRowError  `json:"errors"`
+	// Error — почему задача целиком завершилась неудачей (status failed)
+	Error      string     `json:"error,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ImportRowError почему строка файла не импортирована. Row — номер строки в файле (заголовок — 1),
+// Key — значение ISBN или ID из строки. Ошибки полей — в Errors, ошибка целой строки — в Code и Message
+type ImportRowError struct {
+	Row     int                `json:"row"`
+	Key     string             `json:"key,omitempty"`
+	Code    wrong.Code         `json:"code"`
+	Message string             `json:"message"`
+	Errors  []wrong.FieldError `json:"errors,omitempty"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Виды точек
+const (
+	LocationStore     = "store"
+	LocationWarehouse = "warehouse"
+)
+
+// Location магазин или склад. Координаты нужны только для поиска ближайшей точки
+type Location struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code" validate:"required,max=32"`
+	Name      string    `json:"name" validate:"required,max=255"`
+	Kind      string    `json:"kind" validate:"required,oneof=store warehouse"`
+	Latitude  *float64  `json:"latitude,omitempty" validate:"omitempty,gte=-90,lte=90"`
+	Longitude *float64  `json:"longitude,omitempty" validate:"omitempty,gte=-180,lte=180"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StockLevel остаток книги в одной точке; Available — за вычетом активных резервов.
+// DistanceKm есть, если в запросе были координаты
+type StockLevel struct {
+	LocationID int      `json:"location_id"`
+	Code       string   `json:"code"`
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`
+	Quantity   int      `json:"quantity"`
+	Available  int      `json:"available"`
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+
+	Latitude  *float64 `json:"-"`
+	Longitude *float64 `json:"-"`
+}
+
+// Availability наличие книги: сумма по всем точкам и точки, где она есть.
+// Nearest — ближайшая точка, где доступно нужное количество
+type Availability struct {
+	BookID    int          `json:"book_id"`
+	Total     int          `json:"total"`
+	Available int          `json:"available"`
+	Locations []StockLevel `json:"locations"`
+	Nearest   *StockLevel  `json:"nearest,omitempty"`
+}
+
+// Статусы перемещения
+const (
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+)
+
+// Transfer перемещение между точками. Пока оно в пути, товар не числится ни в одной точке
+type Transfer struct {
+	ID             int        `json:"id"`
+	BookID         int        `json:"book_id" validate:"gt=0"`
+	FromLocationID int        `json:"from_location_id" validate:"gt=0"`
+	ToLocationID   int        `json:"to_location_id" validate:"gt=0"`
+	Quantity       int        `json:"quantity" validate:"gt=0,lte=1000000"`
+	Status         string     `json:"status"`
+	Reference      string     `json:"reference,omitempty" validate:"max=255"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReceivedBy     string     `json:"received_by,omitempty"`
+	ReceivedAt     *time.Time `json:"received_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// OnixSummary итог загрузки ONIX-фида. Skipped — записи, которые ничего не меняют: книга уже
+// в таком виде или удаляется книга, которой нет, поэтому повторная загрузка того же фида безопасна.
+// В Errors Row — порядковый номер записи Product в файле, Key — её ISBN
+type OnixSummary struct {
+	DryRun   bool             `json:"dry_run"`
+	Products int              `json:"products"`
+	Created  int              `json:"created"`
+	Updated  int              `json:"updated"`
+	Deleted  int              `json:"deleted"`
+	Skipped  int              `json:"skipped"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+	// ErrorsTruncated в Errors попали не все ошибки, Failed считает все
+	ErrorsTruncated bool `json:"errors_truncated,omitempty"`
+	// Error — почему чтение фида прервалось; записи до этого места уже применены
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// OrderPlaced статус оформленного заказа
+const OrderPlaced = "placed"
+
+// Order заказ; товар списывается с точки LocationID при оформлении. Цена строк и Total
+// считаются по текущим ценам книг. Заказ по резерву берёт точку и строки из него
+type Order struct {
+	ID            int         `json:"id"`
+	ReservationID int         `json:"reservation_id,omitempty"`
+	LocationID    int         `json:"location_id" validate:"gt=0"`
+	Status        string      `json:"status"`
+	Items         []OrderLine `json:"items" validate:"required,min=1,max=100,dive"`
+	Total         float64     `json:"total"`
+	CreatedBy     string      `json:"created_by"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// OrderLine строка заказа
+type OrderLine struct {
+	BookID   int     `json:"book_id" validate:"gt=0"`
+	Quantity int     `json:"quantity" validate:"gt=0,lte=1000"`
+	Price    float64 `json:"price"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Supplier поставщик; LeadTimeDays — сколько дней идёт поставка после отправки заказа
+type Supplier struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name" validate:"required,max=255"`
+	ContactName  string    `json:"contact_name,omitempty" validate:"max=255"`
+	Email        string    `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	Phone        string    `json:"phone,omitempty" validate:"max=50"`
+	LeadTimeDays int       `json:"lead_time_days" validate:"gte=0,lte=365"`
+	PaymentTerms string    `json:"payment_terms,omitempty" validate:"max=255"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Статусы заказа поставщику
+const (
+	PurchaseDraft             = "draft"
+	PurchaseSent              = "sent"
+	PurchasePartiallyReceived = "partially_received"
+	PurchaseReceived          = "received"
+)
+
+// PurchaseOrder заказ поставщику. Менять строки можно только в черновике; товар приходуется
+// в LocationID (0 — точка по умолчанию). ExpectedAt — дата отправки плюс срок поставки
+type PurchaseOrder struct {
+	ID         int                 `json:"id"`
+	SupplierID int                 `json:"supplier_id" validate:"gt=0"`
+	LocationID int                 `json:"location_id" validate:"gte=0"`
+	Status     string              `json:"status"`
+	Notes      string              `json:"notes,omitempty" validate:"max=1000"`
+	Lines      []PurchaseOrderLine `json:"lines,omitempty" validate:"required,min=1,max=200,dive"`
+	Total      float64             `json:"total"`
+	CreatedBy  string              `json:"created_by"`
+	CreatedAt  time.Time           `json:"created_at"`
+	SentAt     *time.Time          `json:"sent_at,omitempty"`
+	ExpectedAt *time.Time          `json:"expected_at,omitempty"`
+	ReceivedAt *time.Time          `json:"received_at,omitempty"`
+}
+
+// PurchaseOrderLine строка заказа; Line — её номер с 1, на него ссылается приёмка
+type PurchaseOrderLine struct {
+	Line             int     `json:"line"`
+	BookID           int     `json:"book_id" validate:"gt=0"`
+	Quantity         int     `json:"quantity" validate:"gt=0,lte=1000000"`
+	ReceivedQuantity int     `json:"received_quantity"`
+	UnitCost         float64 `json:"unit_cost" validate:"price,lte=1000000"`
+}
+
+// Outstanding сколько по строке ещё не пришло
+func (l *PurchaseOrderLine) Outstanding() int {
+	return l.Quantity - l.ReceivedQuantity
+}
+
+// ReceiptLine сколько пришло по строке заказа
+type ReceiptLine struct {
+	Line     int `json:"line" validate:"gt=0"`
+	Quantity int `json:"quantity" validate:"gt=0,lte=1000000"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// ReorderSuggestion книга на точке заказа или ниже и сколько её докупить. Sold — продано за окно
+// в Days дней, DailySales — средняя скорость продаж за это окно. Quantity — доступный остаток,
+// то есть без активных резервов
+type ReorderSuggestion struct {
+	BookID            int     `json:"book_id"`
+	Title             string  `json:"title"`
+	Author            string  `json:"author"`
+	Quantity          int     `json:"quantity"`
+	ReorderPoint      int     `json:"reorder_point"`
+	ReorderQuantity   int     `json:"reorder_quantity"`
+	Days              int     `json:"days"`
+	Sold              int     `json:"sold"`
+	DailySales        float64 `json:"daily_sales"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Статусы резерва. Просроченный активный резерв считается expired ещё до того, как его обработает сборщик
+const (
+	ReservationActive    = "active"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+	ReservationConverted = "converted"
+)
+
+// Reservation резерв корзины в точке LocationID: держит товар до ExpiresAt и на это время
+// уменьшает доступное к продаже. Оплата превращает его в заказ OrderID
+type Reservation struct {
+	ID         int               `json:"id"`
+	LocationID int               `json:"location_id" validate:"gt=0"`
+	Status     string            `json:"status"`
+	Items      []ReservationLine `json:"items" validate:"required,min=1,max=100,dive"`
+	OrderID    *int              `json:"order_id,omitempty"`
+	CreatedBy  string            `json:"created_by"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+}
+
+// ReservationLine одна книга в резерве
+type ReservationLine struct {
+	BookID   int `json:"book_id" validate:"gt=0"`
+	Quantity int `json:"quantity" validate:"gt=0,lte=1000"`
+}
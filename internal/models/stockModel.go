@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Виды движения товара
+const (
+	StockReceipt    = "receipt"
+	StockSale       = "sale"
+	StockReturn     = "return"
+	StockAdjustment = "adjustment"
+	StockDamage     = "damage"
+	// Перемещение между точками пишется двумя записями: списание при отправке и приход при приёмке
+	StockTransferOut = "transfer_out"
+	StockTransferIn  = "transfer_in"
+)
+
+// StockMovement запись журнала остатков. Delta со знаком: приход и возврат положительные,
+// продажа и списание отрицательные, корректировка — любая кроме нуля. Balance — остаток в точке
+// LocationID после записи; без LocationID движение относится к точке по умолчанию
+type StockMovement struct {
+	ID         int64     `json:"id"`
+	BookID     int       `json:"book_id"`
+	LocationID int       `json:"location_id" validate:"gte=0"`
+	Kind       string    `json:"kind" validate:"required,oneof=receipt sale return adjustment damage"`
+	Delta      int       `json:"delta" validate:"required"`
+	Balance    int       `json:"balance"`
+	Reason     string    `json:"reason,omitempty" validate:"max=500"`
+	Actor      string    `json:"actor"`
+	Reference  string    `json:"reference,omitempty" validate:"max=255"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ValidDelta знак Delta соответствует виду движения
+func (m *StockMovement) ValidDelta() bool {
+	switch m.Kind {
+	case StockReceipt, StockReturn, StockTransferIn:
+		return m.Delta > 0
+	case StockSale, StockDamage, StockTransferOut:
+		return m.Delta < 0
+	default:
+		return m.Delta != 0
+	}
+}
@@ -1,8 +1,34 @@
 package models
 
+// User пользователь в базе и во входящих запросах; наружу отдаётся UserResponse, в нём нет пароля
 type User struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	ID       int    `json:"id" xml:"id"`
+	Username string `json:"username" xml:"username"`
+	Password string `json:"password" xml:"-"`
+	Role     string `json:"role" xml:"role"`
+	// Version растёт при каждом изменении; клиенту приходит как ETag
+	Version int `json:"version" xml:"version"`
+}
+
+// UserResponse пользователь в ответах API во всех форматах
+type UserResponse struct {
+	ID       int    `json:"id" xml:"id"`
+	Username string `json:"username" xml:"username"`
+	Role     string `json:"role" xml:"role"`
+	Version  int    `json:"version" xml:"version"`
+}
+
+// Response пользователь для ответа, без хэша пароля
+func (u *User) Response() *UserResponse {
+	return &UserResponse{ID: u.ID, Username: u.Username, Role: u.Role, Version: u.Version}
+}
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsValidRole проверяет, что роль из списка допустимых
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
 }
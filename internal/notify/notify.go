@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"Bookstore/internal/logging"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Alert оповещение для людей: Subject — одна строка, Text — тело письма или сообщения.
+// Data уходит в вебхук как есть, чтобы получатель мог разобрать его без парсинга текста
+type Alert struct {
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Notifier канал доставки оповещений
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Log пишет оповещение в лог приложения
+type Log struct{}
+
+func (Log) Notify(ctx context.Context, alert Alert) error {
+	logging.FromContext(ctx).Warn(alert.Subject, zap.String("kind", alert.Kind), zap.String("text", alert.Text))
+	return nil
+}
+
+// Email отправляет оповещение письмом через SMTP; Auth может быть nil
+type Email struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+func (e Email) Notify(_ context.Context, alert Alert) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", alert.Subject)
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(alert.Text, "\n", "\r\n"))
+	if err := smtp.SendMail(e.Addr, e.Auth, e.From, e.To, []byte(msg.String())); err != nil {
+		return fmt.Errorf("email to %s: %w", strings.Join(e.To, ", "), err)
+	}
+	return nil
+}
+
+// Webhook отправляет Alert как JSON POST-запросом; успех — любой 2xx ответ
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhook клиент с таймаутом, чтобы зависший получатель не держал воркер
+func NewWebhook(url string) Webhook {
+	return Webhook{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w Webhook) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Multi рассылает оповещение во все каналы; ошибка одного не мешает остальным
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,435 @@
+// Package onix потоковый разбор ONIX 3.0 (reference и short tags): файл читается по одной записи
+// <Product>, поэтому размер фида не ограничен памятью. Из записи берётся только то, что нужно
+// каталогу: ISBN, название, авторы, язык, описание, цены и наличие
+package onix
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NotificationType, при котором запись удаляет товар (ONIX codelist 1)
+const NotificationDelete = "05"
+
+// Product запись ONIX, сведённая к полям каталога
+type Product struct {
+	RecordReference  string
+	NotificationType string
+	// ISBN — ISBN-13 (ProductIDType 15) или GTIN-13 с префиксом 978/979 (ProductIDType 03)
+	ISBN         string
+	Title        string
+	Subtitle     string
+	Contributors []string
+	// Language — код ISO 639-2/B, как в ONIX: eng, rus
+	Language    string
+	Description string
+	// Availability — ProductAvailability (codelist 65) первой SupplyDetail
+	Availability string
+	// OnHand — Stock/OnHand, nil — поставщик остаток не сообщил
+	OnHand *int
+	Prices []Price
+}
+
+// Price цена из SupplyDetail; Type — PriceType (codelist 58), 01 — без налога, 02 — с налогом
+type Price struct {
+	Type     string
+	Amount   float64
+	Currency string
+}
+
+// Deleted запись удаляет товар из каталога
+func (p *Product) Deleted() bool {
+	return p.NotificationType == NotificationDelete
+}
+
+// Unavailable товар больше не поставляется: снят, заменён или распродан у издателя (codelist 65, 40–49 и 51)
+func (p *Product) Unavailable() bool {
+	return strings.HasPrefix(p.Availability, "4") || p.Availability == "51"
+}
+
+// PriceIn цена в валюте currency (пустая — любая): розничная с налогом, если есть, иначе первая подходящая
+func (p *Product) PriceIn(currency string) (float64, bool) {
+	var found *Price
+	for i, price := range p.Prices {
+		if currency != "" && !strings.EqualFold(price.Currency, currency) {
+			continue
+		}
+		if found == nil || (price.Type == "02" && found.Type != "02") {
+			found = &p.Prices[i]
+		}
+	}
+	if found == nil {
+		return 0, false
+	}
+	return found.Amount, true
+}
+
+// languages ISO 639-2/B -> ISO 639-1 для языков, которые встречаются в каталоге
+var languages = map[string]string{
+	"eng": "en", "rus": "ru", "tuk": "tk", "ger": "de", "deu": "de", "fre": "fr", "fra": "fr",
+	"spa": "es", "ita": "it", "por": "pt", "chi": "zh", "zho": "zh", "jpn": "ja", "ukr": "uk",
+	"tur": "tr", "kaz": "kk", "uzb": "uz", "ara": "ar", "pol": "pl", "dut": "nl", "nld": "nl",
+}
+
+// ShortLanguage двухбуквенный код языка записи, если он есть, иначе код из ONIX как есть
+func (p *Product) ShortLanguage() string {
+	if code, ok := languages[p.Language]; ok {
+		return code
+	}
+	return p.Language
+}
+
+// Reader читает записи Product одну за другой
+type Reader struct {
+	dec     *xml.Decoder
+	checked bool
+	count   int
+}
+
+func NewReader(r io.Reader) *Reader {
+	dec := xml.NewDecoder(r)
+	// В описаниях встречается XHTML с &nbsp; и подобными сущностями
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = charsetReader
+	return &Reader{dec: dec}
+}
+
+// Count сколько записей Product уже прочитано
+func (r *Reader) Count() int {
+	return r.count
+}
+
+// Next следующая запись; после последней — io.EOF. Ошибка разбора XML прерывает чтение:
+// дальше в потоке уже нельзя надёжно найти границы записей
+func (r *Reader) Next() (*Product, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) && !r.checked {
+				return nil, errors.New("onix: no ONIXMessage element")
+			}
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		name := canonical(start.Name.Local)
+		if !r.checked {
+			if name != "ONIXMessage" {
+				return nil, fmt.Errorf("onix: root element is %s, not ONIXMessage", start.Name.Local)
+			}
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "release" && !strings.HasPrefix(attr.Value, "3") {
+					return nil, fmt.Errorf("onix: release %s is not supported, only 3.0", attr.Value)
+				}
+			}
+			r.checked = true
+			continue
+		}
+		if name != "Product" {
+			continue
+		}
+
+		n, err := readNode(r.dec, name)
+		if err != nil {
+			return nil, err
+		}
+		r.count++
+		return product(n), nil
+	}
+}
+
+// node элемент записи: имя в reference-форме, собственный текст и дочерние элементы
+type node struct {
+	name     string
+	text     strings.Builder
+	children []*node
+}
+
+// readNode читает элемент целиком после его StartElement
+func readNode(dec *xml.Decoder, name string) (*node, error) {
+	n := &node{name: name}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := readNode(dec, canonical(t.Name.Local))
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		case xml.CharData:
+			n.text.Write(t)
+		case xml.EndElement:
+			return n, nil
+		}
+	}
+}
+
+// all дочерние элементы по пути "A/B/C"
+func (n *node) all(path string) []*node {
+	nodes := []*node{n}
+	for _, name := range strings.Split(path, "/") {
+		var next []*node
+		for _, parent := range nodes {
+			for _, c := range parent.children {
+				if c.name == name {
+					next = append(next, c)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// value текст первого элемента по пути, без пробелов по краям
+func (n *node) value(path string) string {
+	if nodes := n.all(path); len(nodes) > 0 {
+		return nodes[0].innerText()
+	}
+	return ""
+}
+
+// innerText текст элемента вместе с вложенными (XHTML в описаниях), без тегов
+func (n *node) innerText() string {
+	if len(n.children) == 0 {
+		return strings.TrimSpace(n.text.String())
+	}
+	var parts []string
+	if s := strings.TrimSpace(n.text.String()); s != "" {
+		parts = append(parts, s)
+	}
+	for _, c := range n.children {
+		if s := c.innerText(); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func product(n *node) *Product {
+	p := &Product{
+		RecordReference:  n.value("RecordReference"),
+		NotificationType: n.value("NotificationType"),
+	}
+
+	for _, id := range n.all("ProductIdentifier") {
+		value := strings.ReplaceAll(id.value("IDValue"), "-", "")
+		switch id.value("ProductIDType") {
+		case "15":
+			p.ISBN = value
+		case "03":
+			if p.ISBN == "" && (strings.HasPrefix(value, "978") || strings.HasPrefix(value, "979")) {
+				p.ISBN = value
+			}
+		}
+	}
+
+	detail := n.all("DescriptiveDetail")
+	if len(detail) > 0 {
+		d := detail[0]
+		p.Title, p.Subtitle = title(d)
+		p.Contributors = authors(d)
+		for _, lang := range d.all("Language") {
+			if lang.value("LanguageRole") == "01" {
+				p.Language = strings.ToLower(lang.value("LanguageCode"))
+				break
+			}
+		}
+	}
+
+	for _, textType := range []string{"03", "02"} {
+		for _, text := range n.all("CollateralDetail/TextContent") {
+			if text.value("TextType") == textType && p.Description == "" {
+				p.Description = text.value("Text")
+			}
+		}
+	}
+
+	for i, supply := range n.all("ProductSupply/SupplyDetail") {
+		if i == 0 {
+			p.Availability = supply.value("ProductAvailability")
+		}
+		if p.OnHand == nil {
+			for _, stock := range supply.all("Stock") {
+				if onHand, err := strconv.Atoi(stock.value("OnHand")); err == nil {
+					total := onHand
+					if p.OnHand != nil {
+						total += *p.OnHand
+					}
+					p.OnHand = &total
+				}
+			}
+		}
+		for _, price := range supply.all("Price") {
+			amount, err := strconv.ParseFloat(price.value("PriceAmount"), 64)
+			if err != nil {
+				continue
+			}
+			p.Prices = append(p.Prices, Price{Type: price.value("PriceType"), Amount: amount, Currency: price.value("CurrencyCode")})
+		}
+	}
+	return p
+}
+
+// title название товара (TitleType 01); уровень TitleElement 01 (сам товар) важнее серии
+func title(d *node) (string, string) {
+	for _, detail := range d.all("TitleDetail") {
+		if t := detail.value("TitleType"); t != "" && t != "01" {
+			continue
+		}
+		elements := detail.all("TitleElement")
+		sort.SliceStable(elements, func(i, j int) bool {
+			return elements[i].value("TitleElementLevel") == "01" && elements[j].value("TitleElementLevel") != "01"
+		})
+		for _, e := range elements {
+			text := e.value("TitleText")
+			if text == "" {
+				text = strings.TrimSpace(e.value("TitlePrefix") + " " + e.value("TitleWithoutPrefix"))
+			}
+			if text != "" {
+				return text, e.value("Subtitle")
+			}
+		}
+	}
+	return "", ""
+}
+
+// authors авторы (ContributorRole A01) в порядке SequenceNumber
+func authors(d *node) []string {
+	type contributor struct {
+		seq  int
+		name string
+	}
+	var list []contributor
+	for i, c := range d.all("Contributor") {
+		isAuthor := false
+		for _, role := range c.all("ContributorRole") {
+			isAuthor = isAuthor || role.innerText() == "A01"
+		}
+		if !isAuthor {
+			continue
+		}
+		name := c.value("PersonName")
+		if name == "" {
+			name = strings.TrimSpace(c.value("NamesBeforeKey") + " " + c.value("KeyNames"))
+		}
+		if name == "" {
+			name = c.value("CorporateName")
+		}
+		if name == "" {
+			continue
+		}
+		seq, err := strconv.Atoi(c.value("SequenceNumber"))
+		if err != nil {
+			seq = 1000 + i
+		}
+		list = append(list, contributor{seq: seq, name: name})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].seq < list[j].seq })
+
+	names := make([]string, 0, len(list))
+	for _, c := range list {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// shortTags short tags ONIX 3.0 для элементов, которые читает парсер
+var shortTags = map[string]string{
+	"ONIXmessage":       "ONIXMessage",
+	"product":           "Product",
+	"a001":              "RecordReference",
+	"a002":              "NotificationType",
+	"productidentifier": "ProductIdentifier",
+	"b221":              "ProductIDType",
+	"b244":              "IDValue",
+	"descriptivedetail": "DescriptiveDetail",
+	"titledetail":       "TitleDetail",
+	"b202":              "TitleType",
+	"titleelement":      "TitleElement",
+	"x409":              "TitleElementLevel",
+	"b203":              "TitleText",
+	"b030":              "TitlePrefix",
+	"b031":              "TitleWithoutPrefix",
+	"b029":              "Subtitle",
+	"contributor":       "Contributor",
+	"b034":              "SequenceNumber",
+	"b035":              "ContributorRole",
+	"b036":              "PersonName",
+	"b039":              "NamesBeforeKey",
+	"b040":              "KeyNames",
+	"b047":              "CorporateName",
+	"language":          "Language",
+	"b253":              "LanguageRole",
+	"b252":              "LanguageCode",
+	"collateraldetail":  "CollateralDetail",
+	"textcontent":       "TextContent",
+	"x426":              "TextType",
+	"d104":              "Text",
+	"productsupply":     "ProductSupply",
+	"supplydetail":      "SupplyDetail",
+	"j396":              "ProductAvailability",
+	"stock":             "Stock",
+	"j350":              "OnHand",
+	"price":             "Price",
+	"x462":              "PriceType",
+	"j151":              "PriceAmount",
+	"j152":              "CurrencyCode",
+}
+
+// canonical имя элемента в reference-форме
+func canonical(local string) string {
+	if name, ok := shortTags[local]; ok {
+		return name
+	}
+	return local
+}
+
+// charsetReader кроме UTF-8 фиды бывают в ISO-8859-1
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return &latin1Reader{r: input}, nil
+	default:
+		return nil, fmt.Errorf("onix: unsupported encoding %q", label)
+	}
+}
+
+// latin1Reader перекодирует ISO-8859-1 в UTF-8: каждый байт — кодовая точка с тем же номером
+type latin1Reader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	if len(p) < utf8.UTFMax {
+		return 0, io.ErrShortBuffer
+	}
+	if cap(l.buf) < len(p)/2 {
+		l.buf = make([]byte, len(p)/2)
+	}
+	n, err := l.r.Read(l.buf[:len(p)/2])
+	out := 0
+	for _, b := range l.buf[:n] {
+		out += utf8.EncodeRune(p[out:], rune(b))
+	}
+	return out, err
+}
@@ -0,0 +1,215 @@
+package onix
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const referenceFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXMessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/reference">
+  <Header><Sender><SenderName>Publisher</SenderName></Sender></Header>
+  <Product>
+    <RecordReference>pub-1</RecordReference>
+    <NotificationType>03</NotificationType>
+    <ProductIdentifier><ProductIDType>03</ProductIDType><IDValue>9780306406157</IDValue></ProductIdentifier>
+    <ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>978-0-306-40615-7</IDValue></ProductIdentifier>
+    <DescriptiveDetail>
+      <TitleDetail>
+        <TitleType>01</TitleType>
+        <TitleElement><TitleElementLevel>02</TitleElementLevel><TitleText>Chronicles</TitleText></TitleElement>
+        <TitleElement><TitleElementLevel>01</TitleElementLevel><TitlePrefix>The</TitlePrefix><TitleWithoutPrefix>Dune</TitleWithoutPrefix><Subtitle>Book one</Subtitle></TitleElement>
+      </TitleDetail>
+      <Contributor><SequenceNumber>2</SequenceNumber><ContributorRole>A01</ContributorRole><NamesBeforeKey>Brian</NamesBeforeKey><KeyNames>Herbert</KeyNames></Contributor>
+      <Contributor><SequenceNumber>3</SequenceNumber><ContributorRole>B01</ContributorRole><PersonName>Editor</PersonName></Contributor>
+      <Contributor><SequenceNumber>1</SequenceNumber><ContributorRole>A01</ContributorRole><PersonName>Frank Herbert</PersonName></Contributor>
+      <Language><LanguageRole>01</LanguageRole><LanguageCode>ENG</LanguageCode></Language>
+    </DescriptiveDetail>
+    <CollateralDetail>
+      <TextContent><TextType>02</TextType><Text>Short</Text></TextContent>
+      <TextContent><TextType>03</TextType><Text textformat="05"><p>Desert&nbsp;planet</p></Text></TextContent>
+    </CollateralDetail>
+    <ProductSupply>
+      <SupplyDetail>
+        <ProductAvailability>21</ProductAvailability>
+        <Stock><OnHand>5</OnHand></Stock>
+        <Stock><OnHand>7</OnHand></Stock>
+        <Price><PriceType>01</PriceType><PriceAmount>10.00</PriceAmount><CurrencyCode>USD</CurrencyCode></Price>
+        <Price><PriceType>02</PriceType><PriceAmount>12.50</PriceAmount><CurrencyCode>USD</CurrencyCode></Price>
+        <Price><PriceType>02</PriceType><PriceAmount>11.00</PriceAmount><CurrencyCode>EUR</CurrencyCode></Price>
+      </SupplyDetail>
+    </ProductSupply>
+  </Product>
+  <Product>
+    <RecordReference>pub-2</RecordReference>
+    <NotificationType>05</NotificationType>
+    <ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780439420891</IDValue></ProductIdentifier>
+  </Product>
+</ONIXMessage>`
+
+const shortFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXmessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/short">
+  <product>
+    <a001>pub-1</a001>
+    <a002>03</a002>
+    <productidentifier><b221>15</b221><b244>9780306406157</b244></productidentifier>
+    <descriptivedetail>
+      <titledetail><b202>01</b202><titleelement><x409>01</x409><b203>The Dune</b203><b029>Book one</b029></titleelement></titledetail>
+      <contributor><b034>1</b034><b035>A01</b035><b036>Frank Herbert</b036></contributor>
+      <contributor><b034>2</b034><b035>A01</b035><b039>Brian</b039><b040>Herbert</b040></contributor>
+      <language><b253>01</b253><b252>eng</b252></language>
+    </descriptivedetail>
+    <collateraldetail><textcontent><x426>03</x426><d104>Desert planet</d104></textcontent></collateraldetail>
+    <productsupply>
+      <supplydetail>
+        <j396>21</j396>
+        <stock><j350>12</j350></stock>
+        <price><x462>02</x462><j151>12.50</j151><j152>USD</j152></price>
+      </supplydetail>
+    </productsupply>
+  </product>
+</ONIXmessage>`
+
+// readAll все записи фида до io.EOF
+func readAll(t *testing.T, feed io.Reader) []*Product {
+	t.Helper()
+	r := NewReader(feed)
+	var products []*Product
+	for {
+		p, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		products = append(products, p)
+	}
+	if r.Count() != len(products) {
+		t.Errorf("Count() = %d, want %d", r.Count(), len(products))
+	}
+	return products
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// TestReferenceTags полная запись и запись на удаление в reference-форме
+func TestReferenceTags(t *testing.T) {
+	products := readAll(t, strings.NewReader(referenceFeed))
+	if len(products) != 2 {
+		t.Fatalf("got %d products, want 2", len(products))
+	}
+
+	want := &Product{
+		RecordReference:  "pub-1",
+		NotificationType: "03",
+		ISBN:             "9780306406157",
+		Title:            "The Dune",
+		Subtitle:         "Book one",
+		Contributors:     []string{"Frank Herbert", "Brian Herbert"},
+		Language:         "eng",
+		Description:      "Desert planet",
+		Availability:     "21",
+		OnHand:           intPtr(12),
+		Prices: []Price{
+			{Type: "01", Amount: 10, Currency: "USD"},
+			{Type: "02", Amount: 12.5, Currency: "USD"},
+			{Type: "02", Amount: 11, Currency: "EUR"},
+		},
+	}
+	if got := products[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("product = %+v\nwant      %+v", got, want)
+	}
+	if products[0].Deleted() || products[0].Unavailable() {
+		t.Error("first product is reported as deleted or unavailable")
+	}
+	if got := products[0].ShortLanguage(); got != "en" {
+		t.Errorf("ShortLanguage() = %q, want en", got)
+	}
+	if price, ok := products[0].PriceIn("usd"); !ok || price != 12.5 {
+		t.Errorf("PriceIn(usd) = %v, %v; want 12.5 with tax", price, ok)
+	}
+	if price, ok := products[0].PriceIn(""); !ok || price != 12.5 {
+		t.Errorf("PriceIn() = %v, %v; want 12.5", price, ok)
+	}
+	if _, ok := products[0].PriceIn("GBP"); ok {
+		t.Error("PriceIn(GBP) found a price")
+	}
+
+	if !products[1].Deleted() || products[1].ISBN != "9780439420891" {
+		t.Errorf("second product = %+v, want a deletion of 9780439420891", products[1])
+	}
+}
+
+// TestShortTags short tags дают ту же запись, что и reference
+func TestShortTags(t *testing.T) {
+	products := readAll(t, strings.NewReader(shortFeed))
+	if len(products) != 1 {
+		t.Fatalf("got %d products, want 1", len(products))
+	}
+	want := &Product{
+		RecordReference:  "pub-1",
+		NotificationType: "03",
+		ISBN:             "9780306406157",
+		Title:            "The Dune",
+		Subtitle:         "Book one",
+		Contributors:     []string{"Frank Herbert", "Brian Herbert"},
+		Language:         "eng",
+		Description:      "Desert planet",
+		Availability:     "21",
+		OnHand:           intPtr(12),
+		Prices:           []Price{{Type: "02", Amount: 12.5, Currency: "USD"}},
+	}
+	if got := products[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("product = %+v\nwant      %+v", got, want)
+	}
+}
+
+// TestLatin1 фид в ISO-8859-1 перекодируется в UTF-8
+func TestLatin1(t *testing.T) {
+	feed := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<ONIXMessage release=\"3.0\"><Product>" +
+		"<RecordReference>pub-3</RecordReference>" +
+		"<DescriptiveDetail><TitleDetail><TitleElement><TitleText>Caf\xe9 M\xfcller</TitleText></TitleElement></TitleDetail>" +
+		"<Contributor><ContributorRole>A01</ContributorRole><PersonName>Jos\xe9 Sara\xa7</PersonName></Contributor></DescriptiveDetail>" +
+		"</Product></ONIXMessage>"
+	products := readAll(t, strings.NewReader(feed))
+	if len(products) != 1 {
+		t.Fatalf("got %d products, want 1", len(products))
+	}
+	if got := products[0].Title; got != "Café Müller" {
+		t.Errorf("title = %q, want %q", got, "Café Müller")
+	}
+	if got := products[0].Contributors; !reflect.DeepEqual(got, []string{"José Sara§"}) {
+		t.Errorf("contributors = %q", got)
+	}
+}
+
+// TestInvalidFeed не ONIX 3.0 отклоняется на корневом элементе
+func TestInvalidFeed(t *testing.T) {
+	for _, tc := range []struct{ name, feed, want string }{
+		{"other root", `<Catalog><Product/></Catalog>`, "not ONIXMessage"},
+		{"onix 2.1", `<ONIXMessage release="2.1"><Product/></ONIXMessage>`, "only 3.0"},
+		{"empty", ``, "no ONIXMessage"},
+		{"encoding", `<?xml version="1.0" encoding="KOI8-R"?><ONIXMessage/>`, "unsupported encoding"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tc.feed)).Next()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestUnavailable коды ProductAvailability, при которых товар не поставляется
+func TestUnavailable(t *testing.T) {
+	for code, want := range map[string]bool{"20": false, "21": false, "40": true, "46": true, "51": true, "": false} {
+		if got := (&Product{Availability: code}).Unavailable(); got != want {
+			t.Errorf("Unavailable(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
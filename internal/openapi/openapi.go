@@ -0,0 +1,104 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openapi.json ведётся вручную рядом с маршрутами; Check не даёт ему отстать от роутера
+//
+//go:embed openapi.json
+var spec []byte
+
+// Страница Swagger UI; сами скрипты и стили грузятся с CDN
+//
+//go:embed swagger.html
+var docs []byte
+
+// Spec документ OpenAPI 3.1 как есть
+func Spec() []byte {
+	return spec
+}
+
+// Handler отдаёт /openapi.json
+func Handler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", spec)
+}
+
+// DocsHandler отдаёт /docs — Swagger UI поверх /openapi.json
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docs)
+}
+
+// Operations пары "METHOD /path" из документа
+func Operations() (map[string]bool, error) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi.json: %w", err)
+	}
+
+	ops := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				ops[strings.ToUpper(method)+" "+normalize(path)] = true
+			}
+		}
+	}
+	return ops, nil
+}
+
+// Missing маршруты gin, которых нет в документе, в виде "METHOD /path".
+// Маршрут без префикса считается описанным, если описан его алиас aliasOf+path (/books -> /v1/books)
+func Missing(routes gin.RoutesInfo, aliasOf string) ([]string, error) {
+	ops, err := Operations()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, r := range routes {
+		op := r.Method + " " + normalize(r.Path)
+		if !ops[op] && !ops[r.Method+" "+normalize(aliasOf+r.Path)] {
+			missing = append(missing, op)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// Check ошибка, если хотя бы один маршрут не описан в документе
+func Check(routes gin.RoutesInfo, aliasOf string) error {
+	missing, err := Missing(routes, aliasOf)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("routes missing from openapi.json:\n  %s", strings.Join(missing, "\n  "))
+	}
+	return nil
+}
+
+// normalize приводит пути gin и OpenAPI к одному виду: /books/:id -> /books/{id}, без слеша в конце
+func normalize(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
@@ -1,74 +1,245 @@
 package repository
 
 import (
+	"Bookstore/internal/logging"
 	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
-	"log"
 	"strconv"
 )
 
 type BookRepository interface {
-	CreateBook(book *models.Book) error
-	GetAllBooks() ([]*models.Book, error)
-	GetBookByID(id int) (*models.Book, error)
-	Update(book *models.Book) error
-	DeleteBook(id int) error
+	CreateBook(ctx context.Context, book *models.Book) error
+	GetAllBooks(ctx context.Context) ([]*models.Book, error)
+	GetBookByID(ctx context.Context, id int) (*models.Book, error)
+	GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error)
+	NextBookID(ctx context.Context) (int, error)
+	SetSupplierAvailability(ctx context.Context, id int, availability *int) error
+	SearchBooks(ctx context.Context, query string) ([]*models.Book, error)
+	ExportBooks(ctx context.Context, query string, fn func(book *models.Book) error) error
+	Update(ctx context.Context, book *models.Book) error
+	Patch(ctx context.Context, book *models.Book, fields []string) error
+	DeleteBook(ctx context.Context, id, version int) error
 }
 
 type bookRepository struct {
-	db  *sql.DB
-	Log *zap.Logger
+	db *DB
 }
 
-func NewBookRepository(db *sql.DB, logger *zap.Logger) BookRepository {
+// NewBookRepository логгер берётся из контекста каждого вызова (logging.FromContext)
+func NewBookRepository(db *DB) BookRepository {
 	return &bookRepository{
-		db:  db,
-		Log: logger,
+		db: db,
 	}
 }
 
 const (
-	queryCreateBook  = "INSERT INTO books (id, title, author, price, quantity) VALUES ($1, $2, $3, $4, $5)"
-	queryGetAllBooks = "SELECT id, title, author, price, quantity from books"
-	queryGetBookByID = "SELECT id, title, author, price, quantity from books where id = $1"
-	queryUpdateBook  = "UPDATE books SET title = $1, author = $2, price = $3, quantity = $4 WHERE id = $5"
-	queryDeleteBook  = "DELETE FROM books WHERE id = $1"
-)
+	queryCreateBook = `INSERT INTO books (id, isbn, title, author, price, quantity, original_language, translation_of, reorder_point, reorder_quantity)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10) RETURNING version`
+	queryGetAllBooks   = "SELECT id, isbn, title, author, price, quantity, original_language, translation_of, reorder_point, reorder_quantity, cost_price, supplier_availability, version from books ORDER BY id"
+	queryGetBookByID   = "SELECT id, isbn, title, author, price, quantity, original_language, translation_of, reorder_point, reorder_quantity, cost_price, supplier_availability, version from books where id = $1"
+	queryGetBookByISBN = "SELECT id, isbn, title, author, price, quantity, original_language, translation_of, reorder_point, reorder_quantity, cost_price, supplier_availability, version from books where isbn = $1"
+	queryBookVersion   = "SELECT version FROM books WHERE id = $1"
+	queryNextBookID    = "SELECT COALESCE(MAX(id), 0) + 1 FROM books"
+
+	// Данные издателя, а не правка каталога: версия не меняется
+	querySetSupplierAvailability = "UPDATE books SET supplier_availability = $2 WHERE id = $1"
+
+	// Версия 0 — без проверки; иначе строка меняется, только если версия совпала (If-Match).
+	// Остаток версией не защищён: quantity из запроса заменяет текущий, old — остаток до изменения,
+	// разница уходит в журнал stock_movements
+	queryUpdateBook = `UPDATE books b SET title = $1, author = $2, price = $3, quantity = $4, original_language = $5, translation_of = $6,
+		reorder_point = $7, reorder_quantity = $8, isbn = NULLIF($11, ''), version = b.version + 1 FROM (SELECT quantity FROM books WHERE id = $9 FOR UPDATE) old
+		WHERE b.id = $9 AND ($10::int = 0 OR b.version = $10) RETURNING b.version, old.quantity`
+	queryDeleteBook = "DELETE FROM books WHERE id = $1 AND ($2::int = 0 OR version = $2)"
+
+	// Поиск по названию, подзаголовку и описанию на всех языках сразу
+	querySearchBooks = `SELECT b.id, b.isbn, b.title, b.author, b.price, b.quantity, b.original_language, b.translation_of, b.reorder_point, b.reorder_quantity, b.cost_price, b.supplier_availability, b.version
+		FROM books b
+		WHERE EXISTS (SELECT 1 FROM book_translations t WHERE t.book_id = b.id AND t.search @@ plainto_tsquery('simple', $1))
+		ORDER BY b.id`
 
-func (r *bookRepository) CreateBook(book *models.Book) error {
+	// Выгрузка каталога читает книги серверным курсором порциями по 1000
+	queryDeclareExport = "DECLARE books_export NO SCROLL CURSOR FOR "
+	queryFetchExport   = "FETCH 1000 FROM books_export"
 
-	_, err := r.db.Exec(queryCreateBook, book.ID, book.Title, book.Author, book.Price, book.Quantity)
+	queryGetTranslations   = "SELECT book_id, locale, title, subtitle, description FROM book_translations WHERE book_id = ANY($1) ORDER BY book_id, locale"
+	queryUpsertTranslation = `INSERT INTO book_translations (book_id, locale, title, subtitle, description) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (book_id, locale) DO UPDATE SET title = EXCLUDED.title, subtitle = EXCLUDED.subtitle, description = EXCLUDED.description`
+	queryDeleteTranslations = "DELETE FROM book_translations WHERE book_id = $1"
+)
+
+// CreateBook книга и её переводы пишутся в одной транзакции
+func (r *bookRepository) CreateBook(ctx context.Context, book *models.Book) error {
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		err := tx.QueryRowContext(ctx, queryCreateBook, book.ID, book.ISBN, book.Title, book.Author, book.Price, book.Quantity,
+			book.OriginalLanguage, book.TranslationOf, book.ReorderPoint, book.ReorderQuantity).Scan(&book.Version)
+		if err != nil {
+			return err
+		}
+		if err := logQuantityChange(ctx, tx, book, 0, models.StockReceipt, "initial stock"); err != nil {
+			return err
+		}
+		return saveTranslations(ctx, tx, book, false)
+	})
 	if err != nil {
-		r.Log.Error("Error when creating book", zap.String("bookTitle", book.Title), zap.Error(err))
+		if isISBNViolation(err) {
+			return wrong.ErrISBNExists.Wrap(err)
+		}
+		if isUniqueViolation(err) {
+			return wrong.ErrBookExists.Wrap(err)
+		}
+		if isForeignKeyViolation(err) {
+			return wrong.ErrNoOriginalBook.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Error when creating book", zap.String("bookTitle", book.Title), zap.Error(err))
 		return err
 	}
 	return nil
 }
 
-func (r *bookRepository) GetAllBooks() ([]*models.Book, error) {
-	rows, err := r.db.Query(queryGetAllBooks)
+// saveTranslations без переводов в запросе обновляется только язык оригинала из Title;
+// если переводы переданы и replace — они заменяют все сохранённые
+func saveTranslations(ctx context.Context, q Querier, book *models.Book, replace bool) error {
+	translations := book.Translations
+	if len(translations) == 0 {
+		translations = []models.BookTranslation{{Locale: book.OriginalLanguage, Title: book.Title, Subtitle: book.Subtitle, Description: book.Description}}
+	} else if replace {
+		if _, err := q.ExecContext(ctx, queryDeleteTranslations, book.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range translations {
+		if _, err := q.ExecContext(ctx, queryUpsertTranslation, book.ID, t.Locale, t.Title, t.Subtitle, t.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTranslations одним запросом подгружает переводы для всех книг
+func loadTranslations(ctx context.Context, q Querier, books []*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Book, len(books))
+	ids := make([]int64, 0, len(books))
+	for _, b := range books {
+		byID[b.ID] = b
+		ids = append(ids, int64(b.ID))
+	}
+
+	rows, err := q.QueryContext(ctx, queryGetTranslations, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var t models.BookTranslation
+		if err := rows.Scan(&id, &t.Locale, &t.Title, &t.Subtitle, &t.Description); err != nil {
+			return err
+		}
+		if b, ok := byID[id]; ok {
+			b.Translations = append(b.Translations, t)
+		}
+	}
+	return rows.Err()
+}
+
+// scanBook порядок колонок как в queryGetAllBooks
+func scanBook(scan func(dest ...any) error) (*models.Book, error) {
+	book := &models.Book{}
+	var isbn sql.NullString
+	var translationOf sql.NullInt64
+	var costPrice sql.NullFloat64
+	var supplierAvailability sql.NullInt64
+	err := scan(&book.ID, &isbn, &book.Title, &book.Author, &book.Price, &book.Quantity, &book.OriginalLanguage, &translationOf,
+		&book.ReorderPoint, &book.ReorderQuantity, &costPrice, &supplierAvailability, &book.Version)
 	if err != nil {
-		r.Log.Error("Error when querying books", zap.String("query", queryGetAllBooks), zap.Error(err))
 		return nil, err
 	}
-	defer func(rows *sql.Rows) {
+	book.ISBN = isbn.String
+	if translationOf.Valid {
+		id := int(translationOf.Int64)
+		book.TranslationOf = &id
+	}
+	if costPrice.Valid {
+		book.CostPrice = &costPrice.Float64
+	}
+	if supplierAvailability.Valid {
+		n := int(supplierAvailability.Int64)
+		book.SupplierAvailability = &n
+	}
+	return book, nil
+}
+
+func (r *bookRepository) GetAllBooks(ctx context.Context) ([]*models.Book, error) {
+	return r.queryBooks(ctx, queryGetAllBooks)
+}
+
+func (r *bookRepository) SearchBooks(ctx context.Context, query string) ([]*models.Book, error) {
+	return r.queryBooks(ctx, querySearchBooks, query)
+}
+
+func (r *bookRepository) queryBooks(ctx context.Context, query string, args ...any) ([]*models.Book, error) {
+	return collectBooks(ctx, r.db, query, args...)
+}
+
+// ExportBooks отдаёт в fn книги каталога (query — как в SearchBooks, пустой — все) по порядку ID,
+// с переводами и доступным остатком. В памяти одновременно только одна порция курсора;
+// транзакция с курсором открыта, пока идёт выгрузка. Ошибка fn прекращает чтение
+func (r *bookRepository) ExportBooks(ctx context.Context, query string, fn func(book *models.Book) error) error {
+	return r.db.InTx(ctx, func(tx *Tx) error {
+		declare, args := queryDeclareExport+queryGetAllBooks, []any(nil)
+		if query != "" {
+			declare, args = queryDeclareExport+querySearchBooks, []any{query}
+		}
+		if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+			logging.FromContext(ctx).Error("Error when declaring export cursor", zap.Error(err))
+			return err
+		}
+		for {
+			books, err := collectBooks(ctx, tx, queryFetchExport)
+			if err != nil || len(books) == 0 {
+				return err
+			}
+			for _, book := range books {
+				if err := fn(book); err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
+
+// collectBooks книги по запросу вместе с переводами и доступным остатком
+func collectBooks(ctx context.Context, q Querier, query string, args ...any) ([]*models.Book, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying books", zap.String("query", query), zap.Error(err))
+		return nil, err
+	}
+	defer func(rows *Rows) {
 		err := rows.Close()
 		if err != nil {
-			log.Printf(err.Error())
+			logging.FromContext(ctx).Error("Error when closing rows", zap.Error(err))
 		}
 	}(rows)
 
 	var books []*models.Book
 
 	for rows.Next() {
-		book := &models.Book{}
-		// Используем указатели на поля для сканирования данных
-		err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Price, &book.Quantity)
+		book, err := scanBook(rows.Scan)
 		if err != nil {
-			r.Log.Error("Error when scanning books", zap.String("query", queryGetAllBooks), zap.Error(err))
+			logging.FromContext(ctx).Error("Error when scanning books", zap.String("query", query), zap.Error(err))
 			return nil, err
 		}
 		books = append(books, book)
@@ -76,43 +247,245 @@ func (r *bookRepository) GetAllBooks() ([]*models.Book, error) {
 
 	// Проверяем, если ошибка при итерации по строкам
 	if err = rows.Err(); err != nil {
-		log.Printf("Error when iterating over rows: %v", err)
+		logging.FromContext(ctx).Error("Error when iterating over rows", zap.Error(err))
+		return nil, err
+	}
+
+	if err := loadTranslations(ctx, q, books); err != nil {
+		logging.FromContext(ctx).Error("Error when loading book translations", zap.Error(err))
+		return nil, err
+	}
+	if err := loadAvailable(ctx, q, books); err != nil {
+		logging.FromContext(ctx).Error("Error when loading available stock", zap.Error(err))
 		return nil, err
 	}
 	return books, nil
 }
 
-func (r *bookRepository) GetBookByID(id int) (*models.Book, error) {
-	book := &models.Book{}
+func (r *bookRepository) GetBookByID(ctx context.Context, id int) (*models.Book, error) {
+	return r.getBook(ctx, queryGetBookByID, id)
+}
+
+// GetBookByISBN isbn уже нормализован (ISBN-13 без дефисов)
+func (r *bookRepository) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	return r.getBook(ctx, queryGetBookByISBN, isbn)
+}
+
+// NextBookID свободный ID для новой книги, когда его не присылает клиент (импорт без колонки id, ONIX). Не резервируется:
+// если параллельно кто-то занял тот же ID, CreateBook вернёт ErrBookExists и можно взять следующий
+func (r *bookRepository) NextBookID(ctx context.Context) (int, error) {
+	var id int
+	if err := r.db.QueryRowContext(ctx, queryNextBookID).Scan(&id); err != nil {
+		logging.FromContext(ctx).Error("Error when getting next book id", zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
+
+// SetSupplierAvailability сохраняет остаток у издателя из ONIX; nil — издатель его больше не сообщает
+func (r *bookRepository) SetSupplierAvailability(ctx context.Context, id int, availability *int) error {
+	res, err := r.db.ExecContext(ctx, querySetSupplierAvailability, id, availability)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when setting supplier availability", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return wrong.ErrBookNotFound
+	}
+	return nil
+}
 
-	err := r.db.QueryRow(queryGetBookByID, id).Scan(&book.ID, &book.Title, &book.Author, &book.Price, &book.Quantity)
+// getBook одна книга с переводами и доступным остатком по запросу с одним параметром key (id или isbn)
+func (r *bookRepository) getBook(ctx context.Context, query string, key any) (*models.Book, error) {
+	book, err := scanBook(r.db.QueryRowContext(ctx, query, key).Scan)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			r.Log.Warn("book not found", zap.String("title", book.Title))
-			return nil, err
+			logging.FromContext(ctx).Warn("book not found", zap.Any("key", key))
+			return nil, wrong.ErrBookNotFound
 		}
-		r.Log.Error("Error when getting book", zap.String("title", book.Title), zap.Error(err))
+		logging.FromContext(ctx).Error("Error when getting book", zap.Any("key", key), zap.Error(err))
+		return nil, err
+	}
+
+	if err := loadTranslations(ctx, r.db, []*models.Book{book}); err != nil {
+		logging.FromContext(ctx).Error("Error when loading book translations", zap.Any("key", key), zap.Error(err))
+		return nil, err
+	}
+	if err := loadAvailable(ctx, r.db, []*models.Book{book}); err != nil {
+		logging.FromContext(ctx).Error("Error when loading available stock", zap.Any("key", key), zap.Error(err))
 		return nil, err
 	}
-	//r.Log.Info("successfully got book", zap.String("title", book.Title))
 	return book, nil
 }
 
-func (r *bookRepository) Update(book *models.Book) error {
-	_, err := r.db.Exec(queryUpdateBook, book.Title, book.Author, book.Price, book.Quantity, book.ID)
+// Update переданные переводы заменяют сохранённые; без переводов обновляется только язык оригинала.
+// book.Version — ожидаемая версия (0 — без проверки), после успеха в ней новая версия
+func (r *bookRepository) Update(ctx context.Context, book *models.Book) error {
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		var oldQuantity int
+		err := tx.QueryRowContext(ctx, queryUpdateBook, book.Title, book.Author, book.Price, book.Quantity,
+			book.OriginalLanguage, book.TranslationOf, book.ReorderPoint, book.ReorderQuantity, book.ID, book.Version, book.ISBN).Scan(&book.Version, &oldQuantity)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Без этой проверки вставка переводов несуществующей книги упала бы на внешнем ключе
+			return missingOrStale(ctx, tx, book.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := logQuantityChange(ctx, tx, book, oldQuantity, models.StockAdjustment, "quantity set by book update"); err != nil {
+			return err
+		}
+		return saveTranslations(ctx, tx, book, true)
+	})
+	if err != nil {
+		if errors.Is(err, wrong.ErrBookNotFound) || errors.Is(err, wrong.ErrVersionMismatch) || errors.Is(err, wrong.ErrInsufficientStock) {
+			return err
+		}
+		if isForeignKeyViolation(err) {
+			return wrong.ErrNoOriginalBook.Wrap(err)
+		}
+		if isISBNViolation(err) {
+			return wrong.ErrISBNExists.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Error when updating book", zap.Int("id", book.ID), zap.Error(err))
+		return fmt.Errorf("failed to update book: %w", err)
+	}
+	return nil
+}
+
+// bookColumns поля Book, которые можно менять патчем, и их колонки в books
+var bookColumns = map[string]string{
+	"isbn":              "isbn",
+	"title":             "title",
+	"author":            "author",
+	"price":             "price",
+	"quantity":          "quantity",
+	"original_language": "original_language",
+	"translation_of":    "translation_of",
+	"reorder_point":     "reorder_point",
+	"reorder_quantity":  "reorder_quantity",
+}
+
+// bookTranslationFields поля патча, после которых переводы книги сохраняются заново
+var bookTranslationFields = map[string]bool{
+	"title":             true,
+	"subtitle":          true,
+	"description":       true,
+	"original_language": true,
+	"translations":      true,
+}
+
+// logQuantityChange если остаток изменили прямо в books, разница ложится на точку по умолчанию
+// и пишется в журнал
+func logQuantityChange(ctx context.Context, q Querier, book *models.Book, oldQuantity int, kind, reason string) error {
+	delta := book.Quantity - oldQuantity
+	if delta == 0 {
+		return nil
+	}
+	m := &models.StockMovement{BookID: book.ID, Kind: kind, Delta: delta, Reason: reason}
+	if err := adjustLevel(ctx, q, m); err != nil {
+		return err
+	}
+	return insertMovement(ctx, q, m)
+}
+
+// missingOrStale строка не обновилась: книги нет (404) или версия уже другая (412)
+func missingOrStale(ctx context.Context, q Querier, id int) error {
+	var version int
+	err := q.QueryRowContext(ctx, queryBookVersion, id).Scan(&version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return wrong.ErrBookNotFound
+	case err != nil:
+		return err
+	default:
+		return wrong.ErrVersionMismatch
+	}
+}
+
+// Patch обновляет только колонки из fields (json имена полей Book); остальные не трогает.
+// Версия растёт при любом патче, в том числе только переводов
+func (r *bookRepository) Patch(ctx context.Context, book *models.Book, fields []string) error {
+	values := map[string]any{
+		"isbn":              sql.NullString{String: book.ISBN, Valid: book.ISBN != ""},
+		"title":             book.Title,
+		"author":            book.Author,
+		"price":             book.Price,
+		"quantity":          book.Quantity,
+		"original_language": book.OriginalLanguage,
+		"translation_of":    book.TranslationOf,
+		"reorder_point":     book.ReorderPoint,
+		"reorder_quantity":  book.ReorderQuantity,
+	}
+
+	var columns []string
+	var args []any
+	translations := false
+	for _, field := range fields {
+		if column, ok := bookColumns[field]; ok {
+			columns = append(columns, column)
+			args = append(args, values[field])
+		}
+		translations = translations || bookTranslationFields[field]
+	}
+
+	query := "UPDATE books b SET version = b.version + 1"
+	if len(columns) > 0 {
+		query += ", " + setList(columns)
+	}
+	n := len(columns)
+	query += fmt.Sprintf(" FROM (SELECT quantity FROM books WHERE id = $%d FOR UPDATE) old"+
+		" WHERE b.id = $%d AND ($%d::int = 0 OR b.version = $%d) RETURNING b.version, b.quantity, old.quantity", n+1, n+1, n+2, n+2)
+	args = append(args, book.ID, book.Version)
+
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		var oldQuantity int
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&book.Version, &book.Quantity, &oldQuantity)
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingOrStale(ctx, tx, book.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := logQuantityChange(ctx, tx, book, oldQuantity, models.StockAdjustment, "quantity set by book patch"); err != nil {
+			return err
+		}
+		if translations {
+			return saveTranslations(ctx, tx, book, true)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Error when updating book: %v", err)
-		return fmt.Errorf("failed to update book: %v", err)
+		if errors.Is(err, wrong.ErrBookNotFound) || errors.Is(err, wrong.ErrVersionMismatch) || errors.Is(err, wrong.ErrInsufficientStock) {
+			return err
+		}
+		if isForeignKeyViolation(err) {
+			return wrong.ErrNoOriginalBook.Wrap(err)
+		}
+		if isISBNViolation(err) {
+			return wrong.ErrISBNExists.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Error when patching book", zap.Int("id", book.ID), zap.Strings("fields", fields), zap.Error(err))
+		return fmt.Errorf("failed to patch book: %w", err)
 	}
 	return nil
 }
 
-// DeleteBook Delete book
-func (r *bookRepository) DeleteBook(id int) error {
-	_, err := r.db.Exec(queryDeleteBook, id)
+// DeleteBook Delete book; version — ожидаемая версия, 0 — без проверки
+func (r *bookRepository) DeleteBook(ctx context.Context, id, version int) error {
+	res, err := r.db.ExecContext(ctx, queryDeleteBook, id, version)
+	if isForeignKeyViolation(err) {
+		return wrong.ErrBookInUse
+	}
 	if err != nil {
-		r.Log.Error("Error when deleting book", zap.String("id", strconv.Itoa(id)))
-		return fmt.Errorf("unsuccess to delete book: %v", err)
+		logging.FromContext(ctx).Error("Error when deleting book", zap.String("id", strconv.Itoa(id)))
+		return fmt.Errorf("unsuccess to delete book: %w", err)
+	}
+	if err := requireRows(res, wrong.ErrBookNotFound); err != nil {
+		if version == 0 {
+			return err
+		}
+		return missingOrStale(ctx, r.db, id)
 	}
 	return nil
 }
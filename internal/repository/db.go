@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"Bookstore/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DB обёртка над *sql.DB: span и таймаут на каждый SQL запрос
+type DB struct {
+	*sql.DB
+	// StatementTimeout ограничивает один запрос; 0 — только контекст вызывающего
+	StatementTimeout time.Duration
+}
+
+func NewDB(db *sql.DB, statementTimeout time.Duration) *DB {
+	return &DB{DB: db, StatementTimeout: statementTimeout}
+}
+
+// Querier общие методы DB и Tx: хелперы репозиториев работают и внутри транзакции, и без неё
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *Row
+}
+
+// conn методы, общие у *sql.DB и *sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execContext(ctx, d.DB, d.StatementTimeout, query, args...)
+}
+
+// QueryContext span и таймаут живут до Rows.Close
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	return queryContext(ctx, d.DB, d.StatementTimeout, query, args...)
+}
+
+// QueryRowContext span и таймаут живут до Row.Scan, когда известен результат
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	return queryRowContext(ctx, d.DB, d.StatementTimeout, query, args...)
+}
+
+// Tx транзакция с теми же span и таймаутом на каждый запрос, что и у DB
+type Tx struct {
+	tx               *sql.Tx
+	statementTimeout time.Duration
+}
+
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execContext(ctx, t.tx, t.statementTimeout, query, args...)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	return queryContext(ctx, t.tx, t.statementTimeout, query, args...)
+}
+
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	return queryRowContext(ctx, t.tx, t.statementTimeout, query, args...)
+}
+
+// InTx выполняет fn в транзакции: commit, если fn вернула nil, иначе rollback (и при панике тоже)
+func (d *DB) InTx(ctx context.Context, fn func(tx *Tx) error) (err error) {
+	sqlTx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sqlTx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = sqlTx.Rollback()
+		}
+	}()
+
+	if err = fn(&Tx{tx: sqlTx, statementTimeout: d.StatementTimeout}); err != nil {
+		return err
+	}
+	return contextError(ctx, sqlTx.Commit())
+}
+
+// withTimeout добавляет таймаут запроса к контексту вызывающего
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func execContext(ctx context.Context, c conn, timeout time.Duration, query string, args ...any) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	ctx, span := tracing.StartSQL(ctx, query)
+	res, err := c.ExecContext(ctx, query, args...)
+	err = contextError(ctx, err)
+	tracing.EndSQL(span, err)
+	return res, err
+}
+
+func queryContext(ctx context.Context, c conn, timeout time.Duration, query string, args ...any) (*Rows, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	ctx, span := tracing.StartSQL(ctx, query)
+	rows, err := c.QueryContext(ctx, query, args...)
+	if err != nil {
+		err = contextError(ctx, err)
+		tracing.EndSQL(span, err)
+		cancel()
+		return nil, err
+	}
+	return &Rows{Rows: rows, ctx: ctx, span: span, cancel: cancel}, nil
+}
+
+func queryRowContext(ctx context.Context, c conn, timeout time.Duration, query string, args ...any) *Row {
+	ctx, cancel := withTimeout(ctx, timeout)
+	ctx, span := tracing.StartSQL(ctx, query)
+	return &Row{row: c.QueryRowContext(ctx, query, args...), ctx: ctx, span: span, cancel: cancel}
+}
+
+type Rows struct {
+	*sql.Rows
+	ctx    context.Context
+	span   trace.Span
+	cancel context.CancelFunc
+}
+
+func (r *Rows) Err() error {
+	return contextError(r.ctx, r.Rows.Err())
+}
+
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	if r.span != nil {
+		tracing.EndSQL(r.span, r.Err())
+		r.span = nil
+		r.cancel()
+	}
+	return err
+}
+
+type Row struct {
+	row    *sql.Row
+	ctx    context.Context
+	span   trace.Span
+	cancel context.CancelFunc
+}
+
+func (r *Row) Scan(dest ...any) error {
+	err := contextError(r.ctx, r.row.Scan(dest...))
+	tracing.EndSQL(r.span, err)
+	r.cancel()
+	return err
+}
+
+// requireRows notFound, если запрос не затронул ни одной строки: UPDATE и DELETE
+// несуществующей записи не должны молча отвечать успехом
+func requireRows(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// setList "a = $1, b = $2" для SET с параметрами по порядку колонок
+func setList(columns []string) string {
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		sets[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	return strings.Join(sets, ", ")
+}
+
+// isForeignKeyViolation ссылка на несуществующую строку (SQLSTATE 23503)
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// isUniqueViolation нарушение UNIQUE/PRIMARY KEY (SQLSTATE 23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// contextError: драйвер при отмене возвращает свою ошибку ("canceling statement"),
+// оборачиваем её в ctx.Err(), чтобы errors.Is(err, context.DeadlineExceeded) работал выше
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ctx.Err(), err)
+}
+
+// isISBNViolation ISBN уже занят другой книгой
+func isISBNViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "books_isbn_key"
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ImportJobRepository фоновые задачи импорта книг. Файл хранится в задаче до её завершения,
+// ошибки строк дописываются к уже сохранённым, а не перезаписываются целиком
+type ImportJobRepository interface {
+	CreateImportJob(ctx context.Context, job *models.ImportJob, data []byte) error
+	GetImportJob(ctx context.Context, id int) (*models.ImportJob, error)
+	ClaimImportJob(ctx context.Context, staleAfter time.Duration) (*models.ImportJob, []byte, error)
+	SaveImportProgress(ctx context.Context, job *models.ImportJob, newErrors []models.ImportRowError) error
+	FinishImportJob(ctx context.Context, job *models.ImportJob, newErrors []models.ImportRowError) error
+}
+
+type importJobRepository struct {
+	db *DB
+}
+
+func NewImportJobRepository(db *DB) ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+const importJobColumns = `id, status, format, match, dry_run, mapping, total_rows, processed_rows,
+	created_count, updated_count, failed_count, errors, error, created_by, created_at, finished_at`
+
+const (
+	queryCreateImportJob = `INSERT INTO import_jobs (format, match, dry_run, mapping, data, total_rows, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, status, created_at`
+	queryGetImportJob = "SELECT " + importJobColumns + " FROM import_jobs WHERE id = $1"
+	// Берём самую старую задачу в очереди или брошенную: воркер, который её вёл, давно не сохранял прогресс.
+	// SKIP LOCKED — несколько инстансов не возьмут одну задачу
+	queryClaimImportJob = `UPDATE import_jobs SET status = 'running', updated_at = now()
+		WHERE id = (SELECT id FROM import_jobs
+			WHERE status = 'queued' OR (status = 'running' AND updated_at < now() - make_interval(secs => $1))
+			ORDER BY id FOR UPDATE SKIP LOCKED LIMIT 1)
+		RETURNING ` + importJobColumns + ", data"
+	queryImportProgress = `UPDATE import_jobs SET processed_rows = $2, created_count = $3, updated_count = $4, failed_count = $5,
+		errors = errors || $6::jsonb, updated_at = now() WHERE id = $1`
+	queryFinishImportJob = `UPDATE import_jobs SET processed_rows = $2, created_count = $3, updated_count = $4, failed_count = $5,
+		errors = errors || $6::jsonb, status = $7, error = $8, data = NULL, updated_at = now(), finished_at = now()
+		WHERE id = $1 RETURNING finished_at`
+)
+
+// CreateImportJob ставит задачу в очередь; её подхватит воркер (ClaimImportJob)
+func (r *importJobRepository) CreateImportJob(ctx context.Context, job *models.ImportJob, data []byte) error {
+	job.CreatedBy = actor.From(ctx)
+	mapping, err := json.Marshal(job.Mapping)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx, queryCreateImportJob, job.Format, job.Match, job.DryRun, mapping, data, job.TotalRows, job.CreatedBy).
+		Scan(&job.ID, &job.Status, &job.CreatedAt)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when creating import job", zap.Error(err))
+		return err
+	}
+	job.Errors = []models.ImportRowError{}
+	return nil
+}
+
+func (r *importJobRepository) GetImportJob(ctx context.Context, id int) (*models.ImportJob, error) {
+	job, err := scanImportJob(r.db.QueryRowContext(ctx, queryGetImportJob, id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrong.ErrImportJobNotFound
+		}
+		logging.FromContext(ctx).Error("Error when getting import job", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return job, nil
+}
+
+// ClaimImportJob переводит следующую задачу в running и отдаёт её вместе с файлом; nil — очередь пуста
+func (r *importJobRepository) ClaimImportJob(ctx context.Context, staleAfter time.Duration) (*models.ImportJob, []byte, error) {
+	var data []byte
+	job, err := scanImportJob(func(dest ...any) error {
+		return r.db.QueryRowContext(ctx, queryClaimImportJob, staleAfter.Seconds()).Scan(append(dest, &data)...)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when claiming import job", zap.Error(err))
+		return nil, nil, err
+	}
+	return job, data, nil
+}
+
+// SaveImportProgress сохраняет счётчики и дописывает ошибки строк, обработанных с прошлого сохранения
+func (r *importJobRepository) SaveImportProgress(ctx context.Context, job *models.ImportJob, newErrors []models.ImportRowError) error {
+	rowErrors, err := marshalRowErrors(newErrors)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, queryImportProgress, job.ID, job.ProcessedRows, job.Created, job.Updated, job.Failed, rowErrors)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when saving import progress", zap.Int("id", job.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// FinishImportJob как SaveImportProgress, плюс итоговый статус; файл задачи больше не нужен и удаляется
+func (r *importJobRepository) FinishImportJob(ctx context.Context, job *models.ImportJob, newErrors []models.ImportRowError) error {
+	rowErrors, err := marshalRowErrors(newErrors)
+	if err != nil {
+		return err
+	}
+	var finishedAt time.Time
+	err = r.db.QueryRowContext(ctx, queryFinishImportJob, job.ID, job.ProcessedRows, job.Created, job.Updated, job.Failed,
+		rowErrors, job.Status, job.Error).Scan(&finishedAt)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when finishing import job", zap.Int("id", job.ID), zap.Error(err))
+		return err
+	}
+	job.FinishedAt = &finishedAt
+	return nil
+}
+
+func marshalRowErrors(rowErrors []models.ImportRowError) ([]byte, error) {
+	if len(rowErrors) == 0 {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(rowErrors)
+}
+
+// scanImportJob порядок колонок как в importJobColumns
+func scanImportJob(scan func(dest ...any) error) (*models.ImportJob, error) {
+	job := &models.ImportJob{}
+	var mapping, rowErrors []byte
+	var finishedAt sql.NullTime
+	err := scan(&job.ID, &job.Status, &job.Format, &job.Match, &job.DryRun, &mapping, &job.TotalRows, &job.ProcessedRows,
+		&job.Created, &job.Updated, &job.Failed, &rowErrors, &job.Error, &job.CreatedBy, &job.CreatedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(mapping, &job.Mapping); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rowErrors, &job.Errors); err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
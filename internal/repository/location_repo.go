@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+// LocationRepository магазины, склады и остатки книг по ним
+type LocationRepository interface {
+	CreateLocation(ctx context.Context, l *models.Location) error
+	GetAllLocations(ctx context.Context) ([]*models.Location, error)
+	GetLocationByID(ctx context.Context, id int) (*models.Location, error)
+	StockLevels(ctx context.Context, bookID int) ([]models.StockLevel, error)
+}
+
+type locationRepository struct {
+	db *DB
+}
+
+func NewLocationRepository(db *DB) LocationRepository {
+	return &locationRepository{db: db}
+}
+
+const (
+	queryCreateLocation = `INSERT INTO locations (code, name, kind, latitude, longitude) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, is_default, created_at`
+	queryGetAllLocations   = "SELECT id, code, name, kind, latitude, longitude, is_default, created_at FROM locations ORDER BY id"
+	queryGetLocationByID   = "SELECT id, code, name, kind, latitude, longitude, is_default, created_at FROM locations WHERE id = $1"
+	queryLocationExists    = "SELECT EXISTS (SELECT 1 FROM locations WHERE id = $1)"
+	queryDefaultLocation   = "SELECT id FROM locations WHERE is_default"
+	queryStockLevelsByBook = `SELECT l.id, l.code, l.name, l.kind, l.latitude, l.longitude, s.quantity,
+		GREATEST(s.quantity - COALESCE((SELECT SUM(rl.quantity) FROM reservation_lines rl JOIN reservations r ON r.id = rl.reservation_id
+			WHERE rl.book_id = s.book_id AND r.location_id = s.location_id AND ` + activeReservation + `), 0), 0)
+		FROM stock_levels s JOIN locations l ON l.id = s.location_id WHERE s.book_id = $1 AND s.quantity > 0 ORDER BY l.id`
+)
+
+// CreateLocation новая точка никогда не становится точкой по умолчанию
+func (r *locationRepository) CreateLocation(ctx context.Context, l *models.Location) error {
+	err := r.db.QueryRowContext(ctx, queryCreateLocation, l.Code, l.Name, l.Kind, l.Latitude, l.Longitude).
+		Scan(&l.ID, &l.IsDefault, &l.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return wrong.ErrLocationExists.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Error when creating location", zap.String("code", l.Code), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *locationRepository) GetAllLocations(ctx context.Context) ([]*models.Location, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetAllLocations)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying locations", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []*models.Location{}
+	for rows.Next() {
+		l, err := scanLocation(rows.Scan)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error when scanning location", zap.Error(err))
+			return nil, err
+		}
+		locations = append(locations, l)
+	}
+	return locations, rows.Err()
+}
+
+func (r *locationRepository) GetLocationByID(ctx context.Context, id int) (*models.Location, error) {
+	l, err := scanLocation(r.db.QueryRowContext(ctx, queryGetLocationByID, id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrong.ErrLocationNotFound
+		}
+		logging.FromContext(ctx).Error("Error when getting location", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return l, nil
+}
+
+func scanLocation(scan func(dest ...any) error) (*models.Location, error) {
+	l := &models.Location{}
+	if err := scan(&l.ID, &l.Code, &l.Name, &l.Kind, &l.Latitude, &l.Longitude, &l.IsDefault, &l.CreatedAt); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// StockLevels точки, где книга есть в наличии; книги нет — ErrBookNotFound
+func (r *locationRepository) StockLevels(ctx context.Context, bookID int) ([]models.StockLevel, error) {
+	rows, err := r.db.QueryContext(ctx, queryStockLevelsByBook, bookID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying stock levels", zap.Int("bookID", bookID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := []models.StockLevel{}
+	for rows.Next() {
+		var s models.StockLevel
+		if err := rows.Scan(&s.LocationID, &s.Code, &s.Name, &s.Kind, &s.Latitude, &s.Longitude, &s.Quantity, &s.Available); err != nil {
+			logging.FromContext(ctx).Error("Error when scanning stock level", zap.Error(err))
+			return nil, err
+		}
+		levels = append(levels, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(levels) == 0 {
+		if err := missingOrStale(ctx, r.db, bookID); errors.Is(err, wrong.ErrBookNotFound) {
+			return nil, err
+		}
+	}
+	return levels, nil
+}
+
+// requireLocation ErrLocationNotFound, если точки нет
+func requireLocation(ctx context.Context, q Querier, id int) error {
+	var exists bool
+	if err := q.QueryRowContext(ctx, queryLocationExists, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return wrong.ErrLocationNotFound
+	}
+	return nil
+}
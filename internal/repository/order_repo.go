@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// OrderRepository заказы; оформление списывает товар с выбранной точки
+type OrderRepository interface {
+	PlaceOrder(ctx context.Context, o *models.Order) error
+}
+
+type orderRepository struct {
+	db *DB
+}
+
+func NewOrderRepository(db *DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+const (
+	queryBookPrice   = "SELECT price FROM books WHERE id = $1"
+	queryCreateOrder = `INSERT INTO orders (location_id, status, total, created_by) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+	queryOrderLine   = "INSERT INTO order_lines (order_id, line, book_id, quantity, price) VALUES ($1, $2, $3, $4, $5)"
+)
+
+// PlaceOrder в одной транзакции фиксирует цены, создаёт заказ и списывает каждую строку
+// продажей с точки o.LocationID; если хоть одной книги не хватает — не списывается ничего.
+// Чужие активные резервы продать нельзя; заказ по o.ReservationID выкупает свой резерв
+func (r *orderRepository) PlaceOrder(ctx context.Context, o *models.Order) error {
+	o.Status = models.OrderPlaced
+	o.CreatedBy = actor.From(ctx)
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		if o.ReservationID != 0 {
+			if err := claimReservation(ctx, tx, o); err != nil {
+				return err
+			}
+		}
+		if err := requireLocation(ctx, tx, o.LocationID); err != nil {
+			return err
+		}
+		// Книги и их остатки блокируются в порядке id книги, чтобы встречные заказы не ждали друг друга по кругу
+		sort.SliceStable(o.Items, func(i, j int) bool { return o.Items[i].BookID < o.Items[j].BookID })
+
+		o.Total = 0
+		for i := range o.Items {
+			line := &o.Items[i]
+			if err := tx.QueryRowContext(ctx, queryBookPrice, line.BookID).Scan(&line.Price); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return wrong.ErrBookNotFound
+				}
+				return err
+			}
+			o.Total += line.Price * float64(line.Quantity)
+		}
+
+		o.Total = math.Round(o.Total*100) / 100
+
+		if err := tx.QueryRowContext(ctx, queryCreateOrder, o.LocationID, o.Status, o.Total, o.CreatedBy).Scan(&o.ID, &o.CreatedAt); err != nil {
+			return err
+		}
+		reference := "order:" + strconv.Itoa(o.ID)
+		for i, line := range o.Items {
+			if err := requireAvailable(ctx, tx, line.BookID, o.LocationID, line.Quantity, o.ReservationID); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, queryOrderLine, o.ID, i+1, line.BookID, line.Quantity, line.Price); err != nil {
+				return err
+			}
+			err := applyMovement(ctx, tx, &models.StockMovement{
+				BookID:     line.BookID,
+				LocationID: o.LocationID,
+				Kind:       models.StockSale,
+				Delta:      -line.Quantity,
+				Reference:  reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if o.ReservationID != 0 {
+			_, err := tx.ExecContext(ctx, queryConvertReservation, o.ReservationID, o.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, wrong.ErrBookNotFound) || errors.Is(err, wrong.ErrLocationNotFound) || errors.Is(err, wrong.ErrInsufficientStock) ||
+			errors.Is(err, wrong.ErrReservationNotFound) || errors.Is(err, wrong.ErrReservationInactive) {
+			return err
+		}
+		logging.FromContext(ctx).Error("Error when placing order", zap.Int("locationID", o.LocationID), zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+)
+
+// PurchaseOrderRepository заказы поставщикам; приёмка приходует товар через журнал (applyMovement)
+type PurchaseOrderRepository interface {
+	CreatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error
+	GetPurchaseOrderByID(ctx context.Context, id int) (*models.PurchaseOrder, error)
+	GetPurchaseOrders(ctx context.Context, status string, supplierID int, before int64, limit int) ([]*models.PurchaseOrder, error)
+	UpdatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error
+	SendPurchaseOrder(ctx context.Context, id int) (*models.PurchaseOrder, error)
+	ReceivePurchaseOrder(ctx context.Context, id int, receipt []models.ReceiptLine) (*models.PurchaseOrder, error)
+}
+
+type purchaseOrderRepository struct {
+	db *DB
+}
+
+func NewPurchaseOrderRepository(db *DB) PurchaseOrderRepository {
+	return &purchaseOrderRepository{db: db}
+}
+
+const purchaseOrderColumns = `p.id, p.supplier_id, p.location_id, p.status, p.notes, p.created_by, p.created_at, p.sent_at, p.expected_at, p.received_at,
+	(SELECT COALESCE(SUM(l.quantity * l.unit_cost), 0) FROM purchase_order_lines l WHERE l.order_id = p.id)`
+
+const (
+	queryCreatePurchaseOrder = `INSERT INTO purchase_orders (supplier_id, location_id, notes, created_by) VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	queryGetPurchaseOrderByID = "SELECT " + purchaseOrderColumns + " FROM purchase_orders p WHERE p.id = $1"
+	queryGetPurchaseOrders    = "SELECT " + purchaseOrderColumns + ` FROM purchase_orders p
+		WHERE ($1 = '' OR p.status = $1) AND ($2::int = 0 OR p.supplier_id = $2) AND ($3::bigint = 0 OR p.id < $3) ORDER BY p.id DESC LIMIT $4`
+	queryPurchaseOrderStatus = "SELECT status, location_id FROM purchase_orders WHERE id = $1 FOR UPDATE"
+	queryUpdatePurchaseOrder = "UPDATE purchase_orders SET supplier_id = $1, location_id = $2, notes = $3 WHERE id = $4"
+	querySendPurchaseOrder   = `UPDATE purchase_orders p SET status = 'sent', sent_at = now(), expected_at = now() + s.lead_time_days * interval '1 day'
+		FROM suppliers s WHERE p.id = $1 AND s.id = p.supplier_id AND p.status = 'draft'`
+	queryFinishReceipt = `UPDATE purchase_orders SET status = CASE WHEN $2 THEN 'received' ELSE 'partially_received' END,
+		received_at = CASE WHEN $2 THEN now() END WHERE id = $1`
+	queryPurchaseOrderExists = "SELECT EXISTS (SELECT 1 FROM purchase_orders WHERE id = $1)"
+
+	queryPurchaseOrderLines = `SELECT order_id, line, book_id, quantity, received_quantity, unit_cost FROM purchase_order_lines
+		WHERE order_id = ANY($1) ORDER BY order_id, line`
+	queryInsertPurchaseOrderLine  = `INSERT INTO purchase_order_lines (order_id, line, book_id, quantity, unit_cost) VALUES ($1, $2, $3, $4, $5)`
+	queryDeletePurchaseOrderLines = "DELETE FROM purchase_order_lines WHERE order_id = $1"
+	queryReceiveLine              = "UPDATE purchase_order_lines SET received_quantity = received_quantity + $3 WHERE order_id = $1 AND line = $2"
+	// Средневзвешенная закупочная цена: старый остаток по старой цене плюс приход по цене строки
+	queryUpdateCostPrice = `UPDATE books SET cost_price = CASE WHEN cost_price IS NULL OR quantity <= 0 THEN $2
+		ELSE ROUND((cost_price * quantity + $2 * $3) / (quantity + $3), 2) END WHERE id = $1`
+)
+
+// CreatePurchaseOrder черновик заказа; строки нумеруются с 1 в порядке запроса
+func (r *purchaseOrderRepository) CreatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error {
+	po.CreatedBy = actor.From(ctx)
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		if err := preparePurchaseOrder(ctx, tx, po); err != nil {
+			return err
+		}
+		if err := tx.QueryRowContext(ctx, queryCreatePurchaseOrder, po.SupplierID, po.LocationID, po.Notes, po.CreatedBy).Scan(&po.ID); err != nil {
+			return err
+		}
+		return insertPurchaseOrderLines(ctx, tx, po)
+	})
+	if err != nil {
+		return purchaseOrderError(ctx, "Error when creating purchase order", po.ID, err)
+	}
+	return r.reload(ctx, po)
+}
+
+// UpdatePurchaseOrder заменяет поставщика, точку, заметки и строки; только для черновика
+func (r *purchaseOrderRepository) UpdatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error {
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		if err := lockPurchaseOrder(ctx, tx, po.ID, models.PurchaseDraft); err != nil {
+			return err
+		}
+		if err := preparePurchaseOrder(ctx, tx, po); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, queryUpdatePurchaseOrder, po.SupplierID, po.LocationID, po.Notes, po.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, queryDeletePurchaseOrderLines, po.ID); err != nil {
+			return err
+		}
+		return insertPurchaseOrderLines(ctx, tx, po)
+	})
+	if err != nil {
+		return purchaseOrderError(ctx, "Error when updating purchase order", po.ID, err)
+	}
+	return r.reload(ctx, po)
+}
+
+// SendPurchaseOrder draft -> sent; ожидаемая дата поставки считается по сроку поставщика
+func (r *purchaseOrderRepository) SendPurchaseOrder(ctx context.Context, id int) (*models.PurchaseOrder, error) {
+	res, err := r.db.ExecContext(ctx, querySendPurchaseOrder, id)
+	if err != nil {
+		return nil, purchaseOrderError(ctx, "Error when sending purchase order", id, err)
+	}
+	if err := requireRows(res, wrong.ErrPurchaseOrderStatus); err != nil {
+		// Не обновилось: заказа нет или он уже не черновик
+		if errors.Is(err, wrong.ErrPurchaseOrderStatus) {
+			if err := r.requireExists(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	return r.GetPurchaseOrderByID(ctx, id)
+}
+
+// lineBook книга строки заказа; 0 — строки нет
+func lineBook(lines map[int]*models.PurchaseOrderLine, line int) int {
+	if l, ok := lines[line]; ok {
+		return l.BookID
+	}
+	return 0
+}
+
+// ReceivePurchaseOrder приходует пришедшее по строкам: остаток в точке заказа растёт записью receipt,
+// закупочная цена книги пересчитывается. Заказ становится received, когда пришло всё
+func (r *purchaseOrderRepository) ReceivePurchaseOrder(ctx context.Context, id int, receipt []models.ReceiptLine) (*models.PurchaseOrder, error) {
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		var status string
+		var locationID int
+		err := tx.QueryRowContext(ctx, queryPurchaseOrderStatus, id).Scan(&status, &locationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return wrong.ErrPurchaseOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if status != models.PurchaseSent && status != models.PurchasePartiallyReceived {
+			return wrong.ErrPurchaseOrderStatus
+		}
+
+		po := &models.PurchaseOrder{ID: id}
+		if err := loadPurchaseOrderLines(ctx, tx, []*models.PurchaseOrder{po}); err != nil {
+			return err
+		}
+		lines := make(map[int]*models.PurchaseOrderLine, len(po.Lines))
+		for i := range po.Lines {
+			lines[po.Lines[i].Line] = &po.Lines[i]
+		}
+
+		// Книги блокируются в порядке id, как в заказах и резервах; неизвестные строки — в начале, их отклонит проверка ниже
+		sort.SliceStable(receipt, func(i, j int) bool {
+			return lineBook(lines, receipt[i].Line) < lineBook(lines, receipt[j].Line)
+		})
+		reference := "po:" + strconv.Itoa(id)
+		for _, got := range receipt {
+			line, ok := lines[got.Line]
+			switch {
+			case !ok:
+				return wrong.ErrUnknownOrderLine
+			case got.Quantity > line.Outstanding():
+				return wrong.ErrReceiptExceedsOrder
+			}
+			line.ReceivedQuantity += got.Quantity
+
+			if _, err := tx.ExecContext(ctx, queryReceiveLine, id, got.Line, got.Quantity); err != nil {
+				return err
+			}
+			// Цена пересчитывается до прихода: в books.quantity ещё старый остаток
+			if _, err := tx.ExecContext(ctx, queryUpdateCostPrice, line.BookID, line.UnitCost, got.Quantity); err != nil {
+				return err
+			}
+			err := applyMovement(ctx, tx, &models.StockMovement{
+				BookID:     line.BookID,
+				LocationID: locationID,
+				Kind:       models.StockReceipt,
+				Delta:      got.Quantity,
+				Reason:     "purchase order receipt",
+				Reference:  reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		complete := true
+		for _, line := range po.Lines {
+			complete = complete && line.Outstanding() == 0
+		}
+		_, err = tx.ExecContext(ctx, queryFinishReceipt, id, complete)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, wrong.ErrUnknownOrderLine) || errors.Is(err, wrong.ErrReceiptExceedsOrder) {
+			return nil, err
+		}
+		return nil, purchaseOrderError(ctx, "Error when receiving purchase order", id, err)
+	}
+	return r.GetPurchaseOrderByID(ctx, id)
+}
+
+func (r *purchaseOrderRepository) GetPurchaseOrderByID(ctx context.Context, id int) (*models.PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(r.db.QueryRowContext(ctx, queryGetPurchaseOrderByID, id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrong.ErrPurchaseOrderNotFound
+		}
+		logging.FromContext(ctx).Error("Error when getting purchase order", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	if err := loadPurchaseOrderLines(ctx, r.db, []*models.PurchaseOrder{po}); err != nil {
+		logging.FromContext(ctx).Error("Error when loading purchase order lines", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return po, nil
+}
+
+// GetPurchaseOrders от новых к старым без строк; status и supplierID — фильтры (пустые — все)
+func (r *purchaseOrderRepository) GetPurchaseOrders(ctx context.Context, status string, supplierID int, before int64, limit int) ([]*models.PurchaseOrder, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetPurchaseOrders, status, supplierID, before, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying purchase orders", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*models.PurchaseOrder{}
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows.Scan)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error when scanning purchase order", zap.Error(err))
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+	return orders, rows.Err()
+}
+
+// reload после записи возвращает заказ таким, каким его увидит GET
+func (r *purchaseOrderRepository) reload(ctx context.Context, po *models.PurchaseOrder) error {
+	saved, err := r.GetPurchaseOrderByID(ctx, po.ID)
+	if err != nil {
+		return err
+	}
+	*po = *saved
+	return nil
+}
+
+func (r *purchaseOrderRepository) requireExists(ctx context.Context, id int) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, queryPurchaseOrderExists, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return wrong.ErrPurchaseOrderNotFound
+	}
+	return nil
+}
+
+// lockPurchaseOrder блокирует заказ до конца транзакции и проверяет статус
+func lockPurchaseOrder(ctx context.Context, q Querier, id int, status string) error {
+	var current string
+	var locationID int
+	err := q.QueryRowContext(ctx, queryPurchaseOrderStatus, id).Scan(&current, &locationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrong.ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current != status {
+		return wrong.ErrPurchaseOrderStatus
+	}
+	return nil
+}
+
+// preparePurchaseOrder проверяет поставщика и точку; без точки — точка по умолчанию
+func preparePurchaseOrder(ctx context.Context, q Querier, po *models.PurchaseOrder) error {
+	if err := requireSupplier(ctx, q, po.SupplierID); err != nil {
+		return err
+	}
+	if po.LocationID == 0 {
+		return q.QueryRowContext(ctx, queryDefaultLocation).Scan(&po.LocationID)
+	}
+	return requireLocation(ctx, q, po.LocationID)
+}
+
+func insertPurchaseOrderLines(ctx context.Context, q Querier, po *models.PurchaseOrder) error {
+	for i, line := range po.Lines {
+		_, err := q.ExecContext(ctx, queryInsertPurchaseOrderLine, po.ID, i+1, line.BookID, line.Quantity, line.UnitCost)
+		if isForeignKeyViolation(err) {
+			// Поставщик и точка уже проверены — значит, нет книги
+			return wrong.ErrBookNotFound.Wrap(err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPurchaseOrderLines одним запросом подгружает строки всех заказов
+func loadPurchaseOrderLines(ctx context.Context, q Querier, orders []*models.PurchaseOrder) error {
+	byID := make(map[int]*models.PurchaseOrder, len(orders))
+	ids := make([]int64, 0, len(orders))
+	for _, po := range orders {
+		po.Lines = []models.PurchaseOrderLine{}
+		byID[po.ID] = po
+		ids = append(ids, int64(po.ID))
+	}
+
+	rows, err := q.QueryContext(ctx, queryPurchaseOrderLines, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID int
+		var line models.PurchaseOrderLine
+		if err := rows.Scan(&orderID, &line.Line, &line.BookID, &line.Quantity, &line.ReceivedQuantity, &line.UnitCost); err != nil {
+			return err
+		}
+		if po, ok := byID[orderID]; ok {
+			po.Lines = append(po.Lines, line)
+		}
+	}
+	return rows.Err()
+}
+
+func scanPurchaseOrder(scan func(dest ...any) error) (*models.PurchaseOrder, error) {
+	po := &models.PurchaseOrder{}
+	err := scan(&po.ID, &po.SupplierID, &po.LocationID, &po.Status, &po.Notes, &po.CreatedBy, &po.CreatedAt,
+		&po.SentAt, &po.ExpectedAt, &po.ReceivedAt, &po.Total)
+	if err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// purchaseOrderError известные ошибки возвращаются как есть, остальные логируются
+func purchaseOrderError(ctx context.Context, msg string, id int, err error) error {
+	for _, known := range []error{wrong.ErrPurchaseOrderNotFound, wrong.ErrPurchaseOrderStatus, wrong.ErrSupplierNotFound,
+		wrong.ErrLocationNotFound, wrong.ErrBookNotFound} {
+		if errors.Is(err, known) {
+			return err
+		}
+	}
+	logging.FromContext(ctx).Error(msg, zap.Int("id", id), zap.Error(err))
+	return err
+}
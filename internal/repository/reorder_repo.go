@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"context"
+	"go.uber.org/zap"
+)
+
+// ReorderRepository книги на точке заказа и отметки об отправленных алертах
+type ReorderRepository interface {
+	LowStock(ctx context.Context, days int, onlyNew bool) ([]*models.ReorderSuggestion, error)
+	MarkAlerted(ctx context.Context, books []*models.ReorderSuggestion) error
+	ClearRecovered(ctx context.Context) (int64, error)
+}
+
+type reorderRepository struct {
+	db *DB
+}
+
+func NewReorderRepository(db *DB) ReorderRepository {
+	return &reorderRepository{db: db}
+}
+
+// Доступный остаток как в loadAvailable: общий минус активные резервы, не ниже нуля.
+// Зарезервированное уже не продать, поэтому с точкой заказа сравнивается именно он
+const availableStock = `CROSS JOIN LATERAL (SELECT GREATEST(b.quantity - COALESCE((SELECT SUM(l.quantity)
+		FROM reservation_lines l JOIN reservations r ON r.id = l.reservation_id
+		WHERE l.book_id = b.id AND ` + activeReservation + `), 0), 0) AS available) s`
+
+const (
+	// Продажи за $1 дней по журналу; onlyNew ($2) — только книги, о которых ещё не предупреждали
+	queryLowStock = `SELECT b.id, b.title, b.author, s.available, b.reorder_point, b.reorder_quantity,
+		COALESCE((SELECT -SUM(m.delta) FROM stock_movements m
+			WHERE m.book_id = b.id AND m.kind = 'sale' AND m.created_at > now() - $1 * interval '1 day'), 0)
+		FROM books b ` + availableStock + ` WHERE b.reorder_point > 0 AND s.available <= b.reorder_point
+		AND (NOT $2 OR NOT EXISTS (SELECT 1 FROM reorder_alerts a WHERE a.book_id = b.id))
+		ORDER BY b.id`
+	queryMarkAlerted = `INSERT INTO reorder_alerts (book_id, quantity) VALUES ($1, $2)
+		ON CONFLICT (book_id) DO UPDATE SET quantity = EXCLUDED.quantity, alerted_at = now()`
+	queryClearRecovered = `DELETE FROM reorder_alerts a USING books b ` + availableStock + `
+		WHERE a.book_id = b.id AND (b.reorder_point = 0 OR s.available > b.reorder_point)`
+)
+
+func (r *reorderRepository) LowStock(ctx context.Context, days int, onlyNew bool) ([]*models.ReorderSuggestion, error) {
+	rows, err := r.db.QueryContext(ctx, queryLowStock, days, onlyNew)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying low stock", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []*models.ReorderSuggestion{}
+	for rows.Next() {
+		s := &models.ReorderSuggestion{Days: days}
+		if err := rows.Scan(&s.BookID, &s.Title, &s.Author, &s.Quantity, &s.ReorderPoint, &s.ReorderQuantity, &s.Sold); err != nil {
+			logging.FromContext(ctx).Error("Error when scanning low stock", zap.Error(err))
+			return nil, err
+		}
+		books = append(books, s)
+	}
+	return books, rows.Err()
+}
+
+// MarkAlerted после успешной отправки алерта; повторно о книге не предупредят, пока она не восстановится
+func (r *reorderRepository) MarkAlerted(ctx context.Context, books []*models.ReorderSuggestion) error {
+	return r.db.InTx(ctx, func(tx *Tx) error {
+		for _, b := range books {
+			if _, err := tx.ExecContext(ctx, queryMarkAlerted, b.BookID, b.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ClearRecovered снимает отметки с книг, доступный остаток которых снова выше точки заказа
+func (r *reorderRepository) ClearRecovered(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, queryClearRecovered)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when clearing reorder alerts", zap.Error(err))
+		return 0, err
+	}
+	return res.RowsAffected()
+}
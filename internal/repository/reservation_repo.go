@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// ReservationRepository резервы товара в точках; чужие резервы для пользователя не существуют
+type ReservationRepository interface {
+	CreateReservation(ctx context.Context, r *models.Reservation, ttl time.Duration) error
+	GetReservation(ctx context.Context, id int) (*models.Reservation, error)
+	ReleaseReservation(ctx context.Context, id int) error
+	ExpireReservations(ctx context.Context) (int64, error)
+}
+
+type reservationRepository struct {
+	db *DB
+}
+
+func NewReservationRepository(db *DB) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+// activeReservation условие для алиаса r: резерв держит товар, пока он активен и не истёк
+const activeReservation = "r.status = 'active' AND r.expires_at > now()"
+
+const (
+	queryCreateReservation = `INSERT INTO reservations (location_id, created_by, expires_at) VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+		RETURNING id, status, created_at, expires_at`
+	queryReservationLine = "INSERT INTO reservation_lines (reservation_id, book_id, quantity) VALUES ($1, $2, $3)"
+	// Просроченный, но ещё не обработанный сборщиком резерв сразу показывается как expired
+	queryGetReservation = `SELECT r.id, r.location_id, CASE WHEN r.status = 'active' AND r.expires_at <= now() THEN 'expired' ELSE r.status END,
+		r.order_id, r.created_by, r.created_at, r.expires_at FROM reservations r WHERE r.id = $1 AND r.created_by = $2`
+	queryReservationLines   = "SELECT book_id, quantity FROM reservation_lines WHERE reservation_id = $1 ORDER BY book_id"
+	queryLockReservation    = "SELECT r.location_id, " + activeReservation + " FROM reservations r WHERE r.id = $1 AND r.created_by = $2 FOR UPDATE"
+	queryReleaseReservation = "UPDATE reservations r SET status = 'released' WHERE r.id = $1 AND r.created_by = $2 AND " + activeReservation
+	queryConvertReservation = "UPDATE reservations SET status = 'converted', order_id = $2 WHERE id = $1"
+	queryExpireReservations = "UPDATE reservations SET status = 'expired' WHERE status = 'active' AND expires_at <= now()"
+
+	// Строка остатка блокируется до конца транзакции, поэтому параллельные резервы и продажи
+	// одной книги в одной точке проверяют доступное по очереди. Порядок блокировок везде один:
+	// сначала books, потом stock_levels (так же их берут правка книги и applyMovement), иначе взаимоблокировка
+	queryLockBook  = "SELECT id FROM books WHERE id = $1 FOR UPDATE"
+	queryLockLevel = "SELECT quantity FROM stock_levels WHERE book_id = $1 AND location_id = $2 FOR UPDATE"
+	queryReserved  = `SELECT COALESCE(SUM(l.quantity), 0) FROM reservation_lines l JOIN reservations r ON r.id = l.reservation_id
+		WHERE l.book_id = $1 AND r.location_id = $2 AND r.id <> $3 AND ` + activeReservation
+	queryReservedByBook = `SELECT l.book_id, SUM(l.quantity) FROM reservation_lines l JOIN reservations r ON r.id = l.reservation_id
+		WHERE l.book_id = ANY($1) AND ` + activeReservation + ` GROUP BY l.book_id`
+)
+
+// CreateReservation проверяет доступное по каждой строке и создаёт резерв на ttl
+func (r *reservationRepository) CreateReservation(ctx context.Context, res *models.Reservation, ttl time.Duration) error {
+	res.CreatedBy = actor.From(ctx)
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		if err := requireLocation(ctx, tx, res.LocationID); err != nil {
+			return err
+		}
+		// Книги блокируются в порядке id, как в PlaceOrder
+		sort.SliceStable(res.Items, func(i, j int) bool { return res.Items[i].BookID < res.Items[j].BookID })
+		for _, line := range res.Items {
+			if err := requireAvailable(ctx, tx, line.BookID, res.LocationID, line.Quantity, 0); err != nil {
+				return err
+			}
+		}
+
+		err := tx.QueryRowContext(ctx, queryCreateReservation, res.LocationID, res.CreatedBy, ttl.Milliseconds()).
+			Scan(&res.ID, &res.Status, &res.CreatedAt, &res.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		for _, line := range res.Items {
+			if _, err := tx.ExecContext(ctx, queryReservationLine, res.ID, line.BookID, line.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, wrong.ErrBookNotFound) || errors.Is(err, wrong.ErrLocationNotFound) || errors.Is(err, wrong.ErrInsufficientStock) {
+			return err
+		}
+		logging.FromContext(ctx).Error("Error when creating reservation", zap.Int("locationID", res.LocationID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *reservationRepository) GetReservation(ctx context.Context, id int) (*models.Reservation, error) {
+	res := &models.Reservation{}
+	var orderID sql.NullInt64
+	err := r.db.QueryRowContext(ctx, queryGetReservation, id, actor.From(ctx)).
+		Scan(&res.ID, &res.LocationID, &res.Status, &orderID, &res.CreatedBy, &res.CreatedAt, &res.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrong.ErrReservationNotFound
+		}
+		logging.FromContext(ctx).Error("Error when getting reservation", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	if orderID.Valid {
+		id := int(orderID.Int64)
+		res.OrderID = &id
+	}
+
+	if res.Items, err = reservationLines(ctx, r.db, res.ID); err != nil {
+		logging.FromContext(ctx).Error("Error when loading reservation lines", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReleaseReservation досрочно возвращает товар в продажу
+func (r *reservationRepository) ReleaseReservation(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, queryReleaseReservation, id, actor.From(ctx))
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when releasing reservation", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	// Не обновилось: резерва нет или он уже не активен
+	if _, err := r.GetReservation(ctx, id); err != nil {
+		return err
+	}
+	return wrong.ErrReservationInactive
+}
+
+// ExpireReservations переводит истёкшие резервы в expired; возвращает их число
+func (r *reservationRepository) ExpireReservations(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, queryExpireReservations)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when expiring reservations", zap.Error(err))
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func reservationLines(ctx context.Context, q Querier, id int) ([]models.ReservationLine, error) {
+	rows, err := q.QueryContext(ctx, queryReservationLines, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []models.ReservationLine{}
+	for rows.Next() {
+		var line models.ReservationLine
+		if err := rows.Scan(&line.BookID, &line.Quantity); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// claimReservation блокирует активный резерв текущего пользователя и переносит в заказ его точку и строки
+func claimReservation(ctx context.Context, q Querier, o *models.Order) error {
+	var active bool
+	err := q.QueryRowContext(ctx, queryLockReservation, o.ReservationID, actor.From(ctx)).Scan(&o.LocationID, &active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrong.ErrReservationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !active {
+		return wrong.ErrReservationInactive
+	}
+
+	lines, err := reservationLines(ctx, q, o.ReservationID)
+	if err != nil {
+		return err
+	}
+	o.Items = make([]models.OrderLine, 0, len(lines))
+	for _, line := range lines {
+		o.Items = append(o.Items, models.OrderLine{BookID: line.BookID, Quantity: line.Quantity})
+	}
+	return nil
+}
+
+// requireAvailable блокирует книгу и её остаток в точке и проверяет, что за вычетом активных резервов
+// там есть quantity экземпляров; except — резерв, который сейчас выкупается (0 — никакой)
+func requireAvailable(ctx context.Context, q Querier, bookID, locationID, quantity, except int) error {
+	var id int
+	err := q.QueryRowContext(ctx, queryLockBook, bookID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return wrong.ErrBookNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var onHand int
+	err = q.QueryRowContext(ctx, queryLockLevel, bookID, locationID).Scan(&onHand)
+	if errors.Is(err, sql.ErrNoRows) {
+		// В точке книги никогда не было
+		return wrong.ErrInsufficientStock
+	}
+	if err != nil {
+		return err
+	}
+
+	var reserved int
+	if err := q.QueryRowContext(ctx, queryReserved, bookID, locationID, except).Scan(&reserved); err != nil {
+		return err
+	}
+	if onHand-reserved < quantity {
+		return wrong.ErrInsufficientStock
+	}
+	return nil
+}
+
+// loadAvailable заполняет Available: общий остаток минус активные резервы во всех точках
+func loadAvailable(ctx context.Context, q Querier, books []*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Book, len(books))
+	ids := make([]int64, 0, len(books))
+	for _, b := range books {
+		available := b.Quantity
+		b.Available = &available
+		byID[b.ID] = b
+		ids = append(ids, int64(b.ID))
+	}
+
+	rows, err := q.QueryContext(ctx, queryReservedByBook, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, reserved int
+		if err := rows.Scan(&id, &reserved); err != nil {
+			return err
+		}
+		if b, ok := byID[id]; ok {
+			// Списание после резерва может увести разницу в минус — продать всё равно нечего
+			*b.Available = max(*b.Available-reserved, 0)
+		}
+	}
+	return rows.Err()
+}
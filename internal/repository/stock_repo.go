@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+// StockRepository журнал движения товара; остатки в books.quantity и stock_levels меняются только через него
+type StockRepository interface {
+	Record(ctx context.Context, m *models.StockMovement) error
+	History(ctx context.Context, bookID int, before int64, limit int) ([]*models.StockMovement, error)
+}
+
+type stockRepository struct {
+	db *DB
+}
+
+func NewStockRepository(db *DB) StockRepository {
+	return &stockRepository{db: db}
+}
+
+const (
+	// Остаток не уходит в минус: такая строка просто не обновится. Версию книги движение не меняет:
+	// остаток не входит в то, что защищает If-Match, иначе каждая продажа давала бы 412 правке каталога
+	queryApplyStockDelta = "UPDATE books SET quantity = quantity + $2 WHERE id = $1 AND quantity + $2 >= 0 RETURNING quantity"
+	// Приход заводит строку остатка в точке, расход уменьшает существующую и тоже не уходит в минус
+	queryIncreaseLevel = `INSERT INTO stock_levels (book_id, location_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (book_id, location_id) DO UPDATE SET quantity = stock_levels.quantity + EXCLUDED.quantity RETURNING quantity`
+	queryDecreaseLevel  = "UPDATE stock_levels SET quantity = quantity + $3 WHERE book_id = $1 AND location_id = $2 AND quantity + $3 >= 0 RETURNING quantity"
+	queryInsertMovement = `INSERT INTO stock_movements (book_id, location_id, kind, delta, balance, reason, actor, reference)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+	queryStockHistory = `SELECT id, book_id, location_id, kind, delta, balance, reason, actor, reference, created_at FROM stock_movements
+		WHERE book_id = $1 AND ($2::bigint = 0 OR id < $2) ORDER BY id DESC LIMIT $3`
+)
+
+// Record меняет остаток книги и пишет запись журнала в одной транзакции
+func (r *stockRepository) Record(ctx context.Context, m *models.StockMovement) error {
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		return applyMovement(ctx, tx, m)
+	})
+	if err != nil {
+		if errors.Is(err, wrong.ErrBookNotFound) || errors.Is(err, wrong.ErrInsufficientStock) || errors.Is(err, wrong.ErrLocationNotFound) {
+			return err
+		}
+		logging.FromContext(ctx).Error("Error when recording stock movement", zap.Int("bookID", m.BookID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// applyMovement меняет общий остаток книги и остаток в точке и пишет запись журнала;
+// вызывается внутри транзакции, на ошибке её откатывает вызывающий
+func applyMovement(ctx context.Context, q Querier, m *models.StockMovement) error {
+	var total int
+	err := q.QueryRowContext(ctx, queryApplyStockDelta, m.BookID, m.Delta).Scan(&total)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = missingOrStale(ctx, q, m.BookID)
+		if errors.Is(err, wrong.ErrVersionMismatch) {
+			// Книга есть — значит, не хватило остатка
+			return wrong.ErrInsufficientStock
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err := adjustLevel(ctx, q, m); err != nil {
+		return err
+	}
+	return insertMovement(ctx, q, m)
+}
+
+// adjustLevel меняет остаток в точке m.LocationID (0 — точка по умолчанию) и кладёт его в m.Balance
+func adjustLevel(ctx context.Context, q Querier, m *models.StockMovement) error {
+	if m.LocationID == 0 {
+		if err := q.QueryRowContext(ctx, queryDefaultLocation).Scan(&m.LocationID); err != nil {
+			return fmt.Errorf("default location: %w", err)
+		}
+	}
+
+	query := queryIncreaseLevel
+	if m.Delta < 0 {
+		query = queryDecreaseLevel
+	}
+	err := q.QueryRowContext(ctx, query, m.BookID, m.LocationID, m.Delta).Scan(&m.Balance)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Строки остатка нет или не хватает — но сначала проверяем, что точка вообще есть
+		if err := requireLocation(ctx, q, m.LocationID); err != nil {
+			return err
+		}
+		return wrong.ErrInsufficientStock
+	case isForeignKeyViolation(err):
+		return wrong.ErrLocationNotFound.Wrap(err)
+	}
+	return err
+}
+
+// insertMovement только запись журнала; m.Balance и m.LocationID уже посчитаны вызывающим
+func insertMovement(ctx context.Context, q Querier, m *models.StockMovement) error {
+	if m.Actor == "" {
+		m.Actor = actor.From(ctx)
+	}
+	return q.QueryRowContext(ctx, queryInsertMovement, m.BookID, m.LocationID, m.Kind, m.Delta, m.Balance, m.Reason, m.Actor, m.Reference).
+		Scan(&m.ID, &m.CreatedAt)
+}
+
+// History записи журнала от новых к старым; before — id записи, с которой продолжить (0 — с начала)
+func (r *stockRepository) History(ctx context.Context, bookID int, before int64, limit int) ([]*models.StockMovement, error) {
+	rows, err := r.db.QueryContext(ctx, queryStockHistory, bookID, before, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying stock history", zap.Int("bookID", bookID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []*models.StockMovement{}
+	for rows.Next() {
+		m := &models.StockMovement{}
+		if err := rows.Scan(&m.ID, &m.BookID, &m.LocationID, &m.Kind, &m.Delta, &m.Balance, &m.Reason, &m.Actor, &m.Reference, &m.CreatedAt); err != nil {
+			logging.FromContext(ctx).Error("Error when scanning stock movement", zap.Error(err))
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Пустая первая страница — возможно, книги нет
+	if len(movements) == 0 && before == 0 {
+		if err := missingOrStale(ctx, r.db, bookID); errors.Is(err, wrong.ErrBookNotFound) {
+			return nil, err
+		}
+	}
+	return movements, nil
+}
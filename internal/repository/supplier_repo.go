@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+)
+
+// SupplierRepository справочник поставщиков
+type SupplierRepository interface {
+	CreateSupplier(ctx context.Context, s *models.Supplier) error
+	GetAllSuppliers(ctx context.Context) ([]*models.Supplier, error)
+	GetSupplierByID(ctx context.Context, id int) (*models.Supplier, error)
+	UpdateSupplier(ctx context.Context, s *models.Supplier) error
+}
+
+type supplierRepository struct {
+	db *DB
+}
+
+func NewSupplierRepository(db *DB) SupplierRepository {
+	return &supplierRepository{db: db}
+}
+
+const supplierColumns = "id, name, contact_name, email, phone, lead_time_days, payment_terms, created_at"
+
+const (
+	queryCreateSupplier = `INSERT INTO suppliers (name, contact_name, email, phone, lead_time_days, payment_terms)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	queryGetAllSuppliers = "SELECT " + supplierColumns + " FROM suppliers ORDER BY id"
+	queryGetSupplierByID = "SELECT " + supplierColumns + " FROM suppliers WHERE id = $1"
+	queryUpdateSupplier  = `UPDATE suppliers SET name = $1, contact_name = $2, email = $3, phone = $4, lead_time_days = $5, payment_terms = $6
+		WHERE id = $7 RETURNING created_at`
+	querySupplierExists = "SELECT EXISTS (SELECT 1 FROM suppliers WHERE id = $1)"
+)
+
+func (r *supplierRepository) CreateSupplier(ctx context.Context, s *models.Supplier) error {
+	err := r.db.QueryRowContext(ctx, queryCreateSupplier, s.Name, s.ContactName, s.Email, s.Phone, s.LeadTimeDays, s.PaymentTerms).
+		Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return wrong.ErrSupplierExists.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Error when creating supplier", zap.String("name", s.Name), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *supplierRepository) GetAllSuppliers(ctx context.Context) ([]*models.Supplier, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetAllSuppliers)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying suppliers", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []*models.Supplier{}
+	for rows.Next() {
+		s, err := scanSupplier(rows.Scan)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error when scanning supplier", zap.Error(err))
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+	return suppliers, rows.Err()
+}
+
+func (r *supplierRepository) GetSupplierByID(ctx context.Context, id int) (*models.Supplier, error) {
+	s, err := scanSupplier(r.db.QueryRowContext(ctx, queryGetSupplierByID, id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrong.ErrSupplierNotFound
+		}
+		logging.FromContext(ctx).Error("Error when getting supplier", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *supplierRepository) UpdateSupplier(ctx context.Context, s *models.Supplier) error {
+	err := r.db.QueryRowContext(ctx, queryUpdateSupplier, s.Name, s.ContactName, s.Email, s.Phone, s.LeadTimeDays, s.PaymentTerms, s.ID).
+		Scan(&s.CreatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return wrong.ErrSupplierNotFound
+	case isUniqueViolation(err):
+		return wrong.ErrSupplierExists.Wrap(err)
+	case err != nil:
+		logging.FromContext(ctx).Error("Error when updating supplier", zap.Int("id", s.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func scanSupplier(scan func(dest ...any) error) (*models.Supplier, error) {
+	s := &models.Supplier{}
+	if err := scan(&s.ID, &s.Name, &s.ContactName, &s.Email, &s.Phone, &s.LeadTimeDays, &s.PaymentTerms, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// requireSupplier ErrSupplierNotFound, если поставщика нет
+func requireSupplier(ctx context.Context, q Querier, id int) error {
+	var exists bool
+	if err := q.QueryRowContext(ctx, querySupplierExists, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return wrong.ErrSupplierNotFound
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"context"
+	"database/sql"
+	"errors"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+// TransferRepository перемещения между точками; остатки меняются через журнал (applyMovement)
+type TransferRepository interface {
+	CreateTransfer(ctx context.Context, t *models.Transfer) error
+	ReceiveTransfer(ctx context.Context, id int) (*models.Transfer, error)
+	GetTransferByID(ctx context.Context, id int) (*models.Transfer, error)
+	GetTransfers(ctx context.Context, status string, before int64, limit int) ([]*models.Transfer, error)
+}
+
+type transferRepository struct {
+	db *DB
+}
+
+func NewTransferRepository(db *DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+const transferColumns = "id, book_id, from_location_id, to_location_id, quantity, status, reference, created_by, created_at, received_by, received_at"
+
+const (
+	queryCreateTransfer = `INSERT INTO transfers (book_id, from_location_id, to_location_id, quantity, reference, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, status, created_at`
+	// Принять можно только перемещение в пути; повторная приёмка не обновит строку
+	queryReceiveTransfer = "UPDATE transfers SET status = 'received', received_by = $2, received_at = now() WHERE id = $1 AND status = 'in_transit' RETURNING " + transferColumns
+	queryGetTransferByID = "SELECT " + transferColumns + " FROM transfers WHERE id = $1"
+	queryGetTransfers    = "SELECT " + transferColumns + ` FROM transfers
+		WHERE ($1 = '' OR status = $1) AND ($2::bigint = 0 OR id < $2) ORDER BY id DESC LIMIT $3`
+)
+
+// CreateTransfer списывает товар с исходной точки и создаёт перемещение в статусе in_transit
+func (r *transferRepository) CreateTransfer(ctx context.Context, t *models.Transfer) error {
+	t.CreatedBy = actor.From(ctx)
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		for _, id := range []int{t.FromLocationID, t.ToLocationID} {
+			if err := requireLocation(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		err := tx.QueryRowContext(ctx, queryCreateTransfer, t.BookID, t.FromLocationID, t.ToLocationID, t.Quantity, t.Reference, t.CreatedBy).
+			Scan(&t.ID, &t.Status, &t.CreatedAt)
+		if isForeignKeyViolation(err) {
+			// Точки уже проверены — значит, нет книги
+			return wrong.ErrBookNotFound.Wrap(err)
+		}
+		if err != nil {
+			return err
+		}
+		// Зарезервированное покупателями не уезжает
+		if err := requireAvailable(ctx, tx, t.BookID, t.FromLocationID, t.Quantity, 0); err != nil {
+			return err
+		}
+		return applyMovement(ctx, tx, &models.StockMovement{
+			BookID:     t.BookID,
+			LocationID: t.FromLocationID,
+			Kind:       models.StockTransferOut,
+			Delta:      -t.Quantity,
+			Reference:  transferReference(t.ID),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, wrong.ErrBookNotFound) || errors.Is(err, wrong.ErrLocationNotFound) || errors.Is(err, wrong.ErrInsufficientStock) {
+			return err
+		}
+		logging.FromContext(ctx).Error("Error when creating transfer", zap.Int("bookID", t.BookID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ReceiveTransfer приходует товар на точку назначения и закрывает перемещение
+func (r *transferRepository) ReceiveTransfer(ctx context.Context, id int) (*models.Transfer, error) {
+	var t *models.Transfer
+	err := r.db.InTx(ctx, func(tx *Tx) error {
+		var err error
+		t, err = scanTransfer(tx.QueryRowContext(ctx, queryReceiveTransfer, id, actor.From(ctx)).Scan)
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := scanTransfer(tx.QueryRowContext(ctx, queryGetTransferByID, id).Scan); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return wrong.ErrTransferNotFound
+				}
+				return err
+			}
+			return wrong.ErrTransferReceived
+		}
+		if err != nil {
+			return err
+		}
+		return applyMovement(ctx, tx, &models.StockMovement{
+			BookID:     t.BookID,
+			LocationID: t.ToLocationID,
+			Kind:       models.StockTransferIn,
+			Delta:      t.Quantity,
+			Reference:  transferReference(t.ID),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, wrong.ErrTransferNotFound) || errors.Is(err, wrong.ErrTransferReceived) {
+			return nil, err
+		}
+		logging.FromContext(ctx).Error("Error when receiving transfer", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *transferRepository) GetTransferByID(ctx context.Context, id int) (*models.Transfer, error) {
+	t, err := scanTransfer(r.db.QueryRowContext(ctx, queryGetTransferByID, id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrong.ErrTransferNotFound
+		}
+		logging.FromContext(ctx).Error("Error when getting transfer", zap.Int("id", id), zap.Error(err))
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTransfers от новых к старым; status пустой — все, before — id, с которого продолжить
+func (r *transferRepository) GetTransfers(ctx context.Context, status string, before int64, limit int) ([]*models.Transfer, error) {
+	rows, err := r.db.QueryContext(ctx, queryGetTransfers, status, before, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when querying transfers", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []*models.Transfer{}
+	for rows.Next() {
+		t, err := scanTransfer(rows.Scan)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error when scanning transfer", zap.Error(err))
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+func scanTransfer(scan func(dest ...any) error) (*models.Transfer, error) {
+	t := &models.Transfer{}
+	var receivedBy sql.NullString
+	err := scan(&t.ID, &t.BookID, &t.FromLocationID, &t.ToLocationID, &t.Quantity, &t.Status, &t.Reference,
+		&t.CreatedBy, &t.CreatedAt, &receivedBy, &t.ReceivedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.ReceivedBy = receivedBy.String
+	return t, nil
+}
+
+// transferReference ссылка на перемещение в записях журнала
+func transferReference(id int) string {
+	return "transfer:" + strconv.Itoa(id)
+}
@@ -1,10 +1,13 @@
 package repository
 
 import (
+	"Bookstore/internal/logging"
 	"Bookstore/internal/models"
 	"Bookstore/internal/wrong"
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"strconv"
@@ -12,84 +15,88 @@ import (
 
 // UserRepo Интерфейс для операций с пользователями
 type UserRepo interface {
-	CreateUser(user *models.User) error
-	GetUserByUsername(username string) (*models.User, error)
-	GetUserByID(ID int) (*models.User, error)
-	GetAllUsers() ([]*models.User, error)
-	UpdateUser(user *models.User) error
-	DeleteUser(id int) error
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByID(ctx context.Context, ID int) (*models.User, error)
+	GetAllUsers(ctx context.Context) ([]*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	PatchUser(ctx context.Context, user *models.User, fields []string) error
+	DeleteUser(ctx context.Context, id, version int) error
 }
 
 // UserRepository Структура репозитория пользователей
 type UserRepository struct {
-	DB  *sql.DB
-	Log *zap.Logger
+	DB *DB
 }
 
-// NewUserRepository Конструктор UserRepository
-func NewUserRepository(db *sql.DB, logger *zap.Logger) *UserRepository {
-	return &UserRepository{DB: db, Log: logger}
+// NewUserRepository Конструктор UserRepository; логгер берётся из контекста вызова
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{DB: db}
 }
 
 // CreateUser Создание нового пользователя
-func (r *UserRepository) CreateUser(user *models.User) error {
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
-		r.Log.Error("Ошибка хеширования пароля", zap.Error(err))
-		return errors.New("внутренняя ошибка: не удалось создать пользователя")
+		logging.FromContext(ctx).Error("Error hashing password", zap.Error(err))
+		return fmt.Errorf("failed to create user: %w", err)
 	}
 
-	_, err = r.DB.Exec("INSERT INTO users (username, password, role) VALUES ($1, $2, $3)", user.Username, hashedPassword, user.Role)
+	_, err = r.DB.ExecContext(ctx, "INSERT INTO users (username, password, role) VALUES ($1, $2, $3)", user.Username, hashedPassword, user.Role)
 	if err != nil {
-		r.Log.Error("Ошибка базы данных при создании пользователя", zap.String("username", user.Username), zap.Error(err))
-		return errors.New("не удалось создать пользователя")
+		if isUniqueViolation(err) {
+			return wrong.ErrUsernameTaken.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Database error while creating user", zap.String("username", user.Username), zap.Error(err))
+		return fmt.Errorf("failed to create user: %w", err)
 	}
 	return nil
 }
 
 // GetUserByUsername Получение пользователя по имени
-func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 
 	user := &models.User{}
-	err := r.DB.QueryRow("SELECT id, username, password, role FROM users WHERE username = $1", username).
-		Scan(&user.ID, &user.Username, &user.Password, &user.Role)
+	err := r.DB.QueryRowContext(ctx, "SELECT id, username, password, role, version FROM users WHERE username = $1", username).
+		Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Version)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			r.Log.Warn("Пользователь не найден", zap.String("username", username))
+			logging.FromContext(ctx).Warn("User not found", zap.String("username", username))
 			return nil, wrong.ErrUserNotFound
 		}
-		r.Log.Error("Ошибка базы данных при получении пользователя", zap.String("username", username), zap.Error(err))
+		logging.FromContext(ctx).Error("Database error while getting user", zap.String("username", username), zap.Error(err))
 		return nil, err
 	}
 	return user, nil
 }
 
-func (r *UserRepository) GetUserByID(ID int) (*models.User, error) {
+func (r *UserRepository) GetUserByID(ctx context.Context, ID int) (*models.User, error) {
 	user := &models.User{}
-	err := r.DB.QueryRow("SELECT id, username, password, role FROM users WHERE id = $1", ID).Scan(&user.ID, &user.Username, &user.Password, &user.Role)
+	err := r.DB.QueryRowContext(ctx, "SELECT id, username, password, role, version FROM users WHERE id = $1", ID).
+		Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Version)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			r.Log.Warn("User not found", zap.String("user ID", strconv.Itoa(ID)))
+			logging.FromContext(ctx).Warn("User not found", zap.String("user ID", strconv.Itoa(ID)))
 			return nil, wrong.ErrUserNotFound
 		}
-		r.Log.Error("Error database while getting user", zap.String("username", strconv.Itoa(ID)), zap.Error(err))
+		logging.FromContext(ctx).Error("Error database while getting user", zap.String("username", strconv.Itoa(ID)), zap.Error(err))
 		return nil, err
 	}
 	return user, nil
 }
 
 // GetAllUsers Получение всех пользователей
-func (r *UserRepository) GetAllUsers() ([]*models.User, error) {
-	query := "SELECT id, username, password, role FROM users"
-	rows, err := r.DB.Query(query)
+func (r *UserRepository) GetAllUsers(ctx context.Context) ([]*models.User, error) {
+	query := "SELECT id, username, password, role, version FROM users"
+	rows, err := r.DB.QueryContext(ctx, query)
 	if err != nil {
-		r.Log.Error("Ошибка базы данных при получении всех пользователей", zap.Error(err))
+		logging.FromContext(ctx).Error("Database error while getting all users", zap.Error(err))
 		return nil, err
 	}
-	defer func(rows *sql.Rows) {
+	defer func(rows *Rows) {
 		err := rows.Close()
 		if err != nil {
 
@@ -99,58 +106,130 @@ func (r *UserRepository) GetAllUsers() ([]*models.User, error) {
 	var users []*models.User
 	for rows.Next() {
 		user := &models.User{}
-		if err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.Role); err != nil {
-			r.Log.Error("Ошибка при сканировании строки", zap.Error(err))
+		if err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Version); err != nil {
+			logging.FromContext(ctx).Error("Error scanning user row", zap.Error(err))
 			return nil, err
 		}
 		users = append(users, user)
 	}
 
 	if err := rows.Err(); err != nil {
-		r.Log.Error("Ошибка при итерации по строкам", zap.Error(err))
+		logging.FromContext(ctx).Error("Error iterating over user rows", zap.Error(err))
 		return nil, err
 	}
 
 	defer func() {
 		if err := rows.Close(); err != nil {
-			r.Log.Error("Ошибка при закрытии строк результата", zap.Error(err))
+			logging.FromContext(ctx).Error("Error closing user rows", zap.Error(err))
 		}
 	}()
 
 	return users, nil
 }
 
-// UpdateUser Обновление данных пользователя
-func (r *UserRepository) UpdateUser(user *models.User) error {
-	query := "UPDATE users SET username = $1, role = $2 WHERE id = $3"
-	args := []interface{}{user.Username, user.Role, user.ID}
+// UpdateUser Обновление данных пользователя; user.Version — ожидаемая версия (0 — без проверки),
+// после обновления в нём новая
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	columns := []string{"username", "role"}
+	args := []any{user.Username, user.Role}
 
 	if user.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
-			r.Log.Error("Ошибка хеширования пароля", zap.Error(err))
-			return errors.New("внутренняя ошибка: не удалось обновить пользователя")
+			logging.FromContext(ctx).Error("Error hashing password", zap.Error(err))
+			return fmt.Errorf("failed to update user: %w", err)
 		}
-		query = "UPDATE users SET username = $1, role = $2, password = $3 WHERE id = $4"
-		args = append(args[:2], hashedPassword, user.ID)
+		columns, args = append(columns, "password"), append(args, hashedPassword)
 	}
 
-	_, err := r.DB.Exec(query, args...)
-	if err != nil {
-		r.Log.Error("Ошибка базы данных при обновлении пользователя", zap.String("username", user.Username), zap.Error(err))
+	if err := r.updateVersioned(ctx, user, columns, args); err != nil {
+		if errors.Is(err, wrong.ErrUserNotFound) || errors.Is(err, wrong.ErrUserVersionMismatch) {
+			return err
+		}
+		if isUniqueViolation(err) {
+			return wrong.ErrUsernameTaken.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Database error while updating user", zap.String("username", user.Username), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// PatchUser обновляет только поля из fields (username, password, role); пароль хешируется.
+// Версия проверяется и растёт так же, как в UpdateUser
+func (r *UserRepository) PatchUser(ctx context.Context, user *models.User, fields []string) error {
+	var columns []string
+	var args []any
+	for _, field := range fields {
+		switch field {
+		case "username":
+			columns, args = append(columns, "username"), append(args, user.Username)
+		case "role":
+			columns, args = append(columns, "role"), append(args, user.Role)
+		case "password":
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error hashing password", zap.Error(err))
+				return fmt.Errorf("failed to patch user: %w", err)
+			}
+			columns, args = append(columns, "password"), append(args, hashedPassword)
+		}
+	}
+	if err := r.updateVersioned(ctx, user, columns, args); err != nil {
+		if errors.Is(err, wrong.ErrUserNotFound) || errors.Is(err, wrong.ErrUserVersionMismatch) {
+			return err
+		}
+		if isUniqueViolation(err) {
+			return wrong.ErrUsernameTaken.Wrap(err)
+		}
+		logging.FromContext(ctx).Error("Database error while patching user", zap.Int("id", user.ID), zap.Strings("fields", fields), zap.Error(err))
 		return err
 	}
 	return nil
 }
 
-// DeleteUser Удаление пользователя
-func (r *UserRepository) DeleteUser(id int) error {
+// updateVersioned меняет колонки и версию, если версия строки равна user.Version (0 — любая)
+func (r *UserRepository) updateVersioned(ctx context.Context, user *models.User, columns []string, args []any) error {
+	n := len(columns)
+	query := "UPDATE users SET version = version + 1"
+	if n > 0 {
+		query += ", " + setList(columns)
+	}
+	query += fmt.Sprintf(" WHERE id = $%d AND ($%d::int = 0 OR version = $%d) RETURNING version", n+1, n+2, n+2)
+	err := r.DB.QueryRowContext(ctx, query, append(args, user.ID, user.Version)...).Scan(&user.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.missingOrStale(ctx, user.ID)
+	}
+	return err
+}
 
-	_, err := r.DB.Exec("DELETE FROM users WHERE id = $1", id)
-	if err != nil {
-		r.Log.Error("Ошибка базы данных при удалении пользователя", zap.String("id", strconv.Itoa(id)), zap.Error(err))
-		return errors.New("не удалось удалить пользователя")
+// missingOrStale строка не обновилась: пользователя нет (404) или версия уже другая (412)
+func (r *UserRepository) missingOrStale(ctx context.Context, id int) error {
+	var version int
+	err := r.DB.QueryRowContext(ctx, "SELECT version FROM users WHERE id = $1", id).Scan(&version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return wrong.ErrUserNotFound
+	case err != nil:
+		return err
+	default:
+		return wrong.ErrUserVersionMismatch
 	}
+}
+
+// DeleteUser Удаление пользователя; version — ожидаемая версия, 0 — без проверки
+func (r *UserRepository) DeleteUser(ctx context.Context, id, version int) error {
 
+	res, err := r.DB.ExecContext(ctx, "DELETE FROM users WHERE id = $1 AND ($2::int = 0 OR version = $2)", id, version)
+	if err != nil {
+		logging.FromContext(ctx).Error("Database error while deleting user", zap.String("id", strconv.Itoa(id)), zap.Error(err))
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if err := requireRows(res, wrong.ErrUserNotFound); err != nil {
+		if version == 0 {
+			return err
+		}
+		return r.missingOrStale(ctx, id)
+	}
 	return nil
 }
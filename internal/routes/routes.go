@@ -2,54 +2,164 @@ package routes
 
 import (
 	"Bookstore/internal/handler"
+	"Bookstore/internal/health"
+	"Bookstore/internal/metrics"
 	"Bookstore/internal/middleware"
+	"Bookstore/internal/openapi"
+	"Bookstore/internal/tracing"
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap" // Используем zap для логирования
+	"go.uber.org/zap"
 )
 
+// Config версии API. Legacy — маршруты без префикса, временные алиасы /v1;
+// V1 заполняется, когда /v2 полностью заменит /v1
+type Config struct {
+	Legacy middleware.Deprecation
+	V1     *middleware.Deprecation
+}
+
+// Handlers HTTP хендлеры всех ресурсов
+type Handlers struct {
+	Auth          *handler.AuthHandler
+	Book          *handler.BookHandler
+	Stock         *handler.StockHandler
+	Location      *handler.LocationHandler
+	Transfer      *handler.TransferHandler
+	Order         *handler.OrderHandler
+	Reservation   *handler.ReservationHandler
+	Reorder       *handler.ReorderHandler
+	Supplier      *handler.SupplierHandler
+	PurchaseOrder *handler.PurchaseOrderHandler
+	BookImport    *handler.BookImportHandler
+	Onix          *handler.OnixHandler
+	BookExport    *handler.BookExportHandler
+}
+
+// Version версия API: префикс, политика устаревания и функция, регистрирующая её маршруты.
+// open — маршруты без токена, protected — за AuthRequired
+type Version struct {
+	Name        string
+	Prefix      string
+	Deprecation *middleware.Deprecation
+	Register    func(open, protected *gin.RouterGroup)
+}
+
+// Versions версии в порядке регистрации. /v2 добавляется сюда отдельной записью со своими
+// хендлерами, /v1 при этом продолжает работать рядом
+func Versions(cfg Config, h Handlers) []Version {
+	v1 := func(open, protected *gin.RouterGroup) { registerV1(open, protected, h) }
+	legacy := cfg.Legacy
+	return []Version{
+		{Name: "v1", Prefix: "/v1", Deprecation: cfg.V1, Register: v1},
+		{Name: "legacy", Prefix: "", Deprecation: &legacy, Register: v1},
+	}
+}
+
 // SetupRoutes — это функция, которая регистрирует маршруты в Gin
-func SetupRoutes(authHandler *handler.AuthHandler, bookHandler *handler.BookHandler) *gin.Engine {
-	// Инициализация логгера zap
-	logger, _ := zap.NewProduction()
-	defer logger.Sync() // Закрываем логгер после завершения работы
+func SetupRoutes(logger *zap.Logger, checks *health.Registry, reg *metrics.Prometheus, cfg Config, h Handlers) *gin.Engine {
+	// gin.Default() пишет свой access log; вместо него RequestLogger с request_id
+	router := gin.New()
+	// Metrics снаружи Recovery: 500 после паники тоже попадает в метрики
+	router.Use(tracing.Middleware(), middleware.RequestLogger(logger), middleware.Locale(), middleware.Metrics(reg),
+		middleware.Recovery(), middleware.Problems())
+	router.NoRoute(middleware.NoRoute)
+
+	// Проверки и метрики для оркестратора — вне версий и без токена
+	router.GET("/healthz", checks.LiveHandler)
+	router.GET("/readyz", checks.ReadyHandler)
+	router.GET("/metrics", gin.WrapH(reg.Handler()))
 
-	router := gin.Default()
+	// Описание API открыто, как и регистрация
+	router.GET("/openapi.json", openapi.Handler)
+	router.GET("/docs", openapi.DocsHandler)
+
+	for _, v := range Versions(cfg, h) {
+		open := router.Group(v.Prefix, middleware.APIVersion(reg, v.Name, v.Prefix, v.Deprecation))
+		protected := open.Group("", middleware.AuthRequired())
+		v.Register(open, protected)
+	}
+	return router
+}
 
+// registerV1 маршруты первой версии API
+func registerV1(open, protected *gin.RouterGroup, h Handlers) {
 	// Маршруты аутентификации
-	authGroup := router.Group("/auth")
+	authGroup := open.Group("/auth")
 	{
-		authGroup.POST("/register", authHandler.Register)
-		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/register", h.Auth.Register)
+		authGroup.POST("/login", h.Auth.Login)
 	}
 
-	// Middleware аутентификации для защищенных маршрутов
-	router.Use(middleware.AuthRequired())
-
 	// Маршруты для работы с пользователями
-	usersGroup := router.Group("/users")
+	usersGroup := protected.Group("/users")
 	{
-		usersGroup.GET("/", authHandler.GetAllUser)
-		router.GET("/users/id/:id", authHandler.GetUserByID)
-		router.GET("/users/username/:username", authHandler.GetUserByUsername)
-		usersGroup.PUT("/:id", authHandler.UpdateUser)
-		usersGroup.DELETE("/:id", authHandler.DeleteUser)
+		usersGroup.GET("/", h.Auth.GetAllUser)
+		usersGroup.GET("/id/:id", h.Auth.GetUserByID)
+		usersGroup.GET("/username/:username", h.Auth.GetUserByUsername)
+		usersGroup.PUT("/:id", h.Auth.UpdateUser)
+		usersGroup.PATCH("/:id", h.Auth.PatchUser)
+		usersGroup.DELETE("/:id", h.Auth.DeleteUser)
 	}
 
 	// Маршруты для работы с книгами
-	booksGroup := router.Group("/books")
+	booksGroup := protected.Group("/books")
 	{
-		booksGroup.GET("/", bookHandler.GetAllBook)
-		booksGroup.GET("/:id", bookHandler.GetBookByID)
+		booksGroup.GET("/", h.Book.GetAllBook)
+		booksGroup.GET("/:id", h.Book.GetBookByID)
+		booksGroup.GET("/:id/availability", h.Location.Availability)
 	}
 
-	// Админские маршруты для управления книгами, только для администраторов
-	adminGroup := router.Group("/admin")
-	adminGroup.Use(middleware.AuthRequired()) // Добавляем отдельное middleware для проверки роли администратора
+	// Магазины и склады; заказ списывает товар с выбранной точки
+	protected.GET("/locations", h.Location.GetAllLocations)
+	protected.POST("/checkout", h.Order.Checkout)
+
+	// Резерв держит товар корзины, пока покупатель платит
+	reservationsGroup := protected.Group("/reservations")
 	{
-		logger.Info("Setting up /admin routes...")
-		adminGroup.POST("/books", bookHandler.CreateBookHandler)
-		adminGroup.PUT("/books/:id", bookHandler.UpdateBookHandler)
-		adminGroup.DELETE("/books/:id", bookHandler.DeleteBookHandler)
+		reservationsGroup.POST("", h.Reservation.CreateReservation)
+		reservationsGroup.GET("/:id", h.Reservation.GetReservation)
+		reservationsGroup.DELETE("/:id", h.Reservation.ReleaseReservation)
+	}
+
+	// Админские маршруты: изменение каталога, склад, закупки — только для роли admin
+	adminGroup := protected.Group("/admin", middleware.AdminOnly())
+	{
+		adminGroup.POST("/books", h.Book.CreateBookHandler)
+		adminGroup.PUT("/books/:id", h.Book.UpdateBookHandler)
+		adminGroup.PATCH("/books/:id", h.Book.PatchBookHandler)
+		adminGroup.DELETE("/books/:id", h.Book.DeleteBookHandler)
+
+		// Загрузка каталога из CSV/XLSX; большие файлы обрабатываются в фоне
+		adminGroup.POST("/books/import", h.BookImport.Import)
+		adminGroup.GET("/books/import/:id", h.BookImport.GetImportJob)
+		// ONIX 3.0 фид издателя, читается потоково
+		adminGroup.POST("/books/onix", h.Onix.Ingest)
+		// Выгрузка каталога потоком: CSV, JSON Lines или XLSX
+		adminGroup.GET("/books/export", h.BookExport.Export)
+
+		// Остаток меняется только записями журнала
+		adminGroup.POST("/books/:id/stock-adjustments", h.Stock.CreateAdjustment)
+		adminGroup.GET("/books/:id/stock-history", h.Stock.History)
+
+		adminGroup.POST("/locations", h.Location.CreateLocation)
+		adminGroup.POST("/transfers", h.Transfer.CreateTransfer)
+		adminGroup.GET("/transfers", h.Transfer.GetTransfers)
+		adminGroup.GET("/transfers/:id", h.Transfer.GetTransferByID)
+		adminGroup.POST("/transfers/:id/receive", h.Transfer.ReceiveTransfer)
+
+		adminGroup.GET("/reports/reorder", h.Reorder.Report)
+
+		// Закупки: поставщики и заказы им; приёмка приходует товар
+		adminGroup.POST("/suppliers", h.Supplier.CreateSupplier)
+		adminGroup.GET("/suppliers", h.Supplier.GetAllSuppliers)
+		adminGroup.GET("/suppliers/:id", h.Supplier.GetSupplierByID)
+		adminGroup.PUT("/suppliers/:id", h.Supplier.UpdateSupplier)
+
+		adminGroup.POST("/purchase-orders", h.PurchaseOrder.CreatePurchaseOrder)
+		adminGroup.GET("/purchase-orders", h.PurchaseOrder.GetPurchaseOrders)
+		adminGroup.GET("/purchase-orders/:id", h.PurchaseOrder.GetPurchaseOrderByID)
+		adminGroup.PUT("/purchase-orders/:id", h.PurchaseOrder.UpdatePurchaseOrder)
+		adminGroup.POST("/purchase-orders/:id/send", h.PurchaseOrder.SendPurchaseOrder)
+		adminGroup.POST("/purchase-orders/:id/receipts", h.PurchaseOrder.ReceivePurchaseOrder)
 	}
-	return router
 }
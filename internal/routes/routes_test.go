@@ -0,0 +1,167 @@
+package routes_test
+
+import (
+	"Bookstore/internal/app"
+	"Bookstore/internal/health"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/middleware"
+	"Bookstore/internal/models"
+	"Bookstore/internal/openapi"
+	"Bookstore/internal/routes"
+	"Bookstore/internal/tracing"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// newRouter роутер приложения со всеми маршрутами. База не поднимается: sql.Open соединение не открывает,
+// а запрос, дошедший до SQL, получает отказ в соединении
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=bookstore dbname=bookstore sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	reg := metrics.NewPrometheus()
+	_, handlers := app.InitApp(db, reg)
+	return routes.SetupRoutes(zap.NewNop(), health.NewRegistry(), reg, app.LoadRoutesConfig(), handlers)
+}
+
+// TestOpenAPICoversRoutes каждый маршрут /v1 описан в openapi.json
+func TestOpenAPICoversRoutes(t *testing.T) {
+	if err := openapi.Check(newRouter(t).Routes(), "/v1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAdminRoutesRequireAdmin токен обычного пользователя в админские маршруты не пускает
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	router := newRouter(t)
+	for _, tc := range []struct {
+		role string
+		want int
+	}{
+		{models.RoleUser, http.StatusForbidden},
+		{models.RoleAdmin, http.StatusBadRequest},
+	} {
+		token, err := middleware.GenerateAccessToken("someone", tc.role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/books", strings.NewReader("{"))
+		req.Header.Set("Authorization", token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("role %s: status %d, want %d: %s", tc.role, w.Code, tc.want, w.Body)
+		}
+	}
+}
+
+// TestMutationsRequireIfMatch изменение книги или пользователя без If-Match — 428, до обращения к базе
+func TestMutationsRequireIfMatch(t *testing.T) {
+	router := newRouter(t)
+	token, err := middleware.GenerateAccessToken("root", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPut, "/v1/admin/books/1", `{"id":1,"title":"T","author":"A","price":1,"quantity":1}`},
+		{http.MethodPatch, "/v1/admin/books/1", `{"title":"T"}`},
+		{http.MethodDelete, "/v1/admin/books/1", ""},
+		{http.MethodPut, "/v1/users/1", `{"username":"root","role":"admin"}`},
+		{http.MethodPatch, "/v1/users/1", `{"username":"root"}`},
+		{http.MethodDelete, "/v1/users/1", ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Authorization", token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusPreconditionRequired {
+			t.Errorf("%s %s: status %d, want 428: %s", tc.method, tc.path, w.Code, w.Body)
+		}
+	}
+}
+
+// TestTracingSpans запрос продолжает входящий traceparent, а span сервиса и SQL вложены в span запроса
+func TestTracingSpans(t *testing.T) {
+	provider, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterMemory, ServiceName: "bookstore-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+	router := newRouter(t)
+
+	token, err := middleware.GenerateAccessToken("reader", models.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/v1/books/1", nil)
+	req.Header.Set("Authorization", token)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("traceparent"); !strings.Contains(got, traceID) {
+		t.Errorf("response traceparent = %q, want trace %s", got, traceID)
+	}
+
+	spans := provider.Memory.GetSpans()
+	find := func(match func(tracetest.SpanStub) bool, what string) tracetest.SpanStub {
+		t.Helper()
+		for _, span := range spans {
+			if match(span) {
+				return span
+			}
+		}
+		t.Fatalf("no %s span among %d", what, len(spans))
+		return tracetest.SpanStub{}
+	}
+	server := find(func(s tracetest.SpanStub) bool { return s.Name == "GET /v1/books/:id" }, "server")
+	svc := find(func(s tracetest.SpanStub) bool { return s.Name == "BookService.GetBookByID" }, "service")
+	query := find(func(s tracetest.SpanStub) bool { return strings.HasPrefix(s.Name, "SQL ") }, "SQL")
+
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v", server.SpanKind)
+	}
+	if server.SpanContext.TraceID().String() != traceID || server.Parent.SpanID().String() != parentSpanID {
+		t.Errorf("server span is not a child of the incoming traceparent: trace %s, parent %s",
+			server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+	if svc.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("service span parent = %s, want server span %s", svc.Parent.SpanID(), server.SpanContext.SpanID())
+	}
+	if query.Parent.SpanID() != svc.SpanContext.SpanID() {
+		t.Errorf("SQL span parent = %s, want service span %s", query.Parent.SpanID(), svc.SpanContext.SpanID())
+	}
+	if query.SpanKind != trace.SpanKindClient {
+		t.Errorf("SQL span kind = %v", query.SpanKind)
+	}
+	for _, span := range []tracetest.SpanStub{svc, query} {
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Errorf("span %s is in trace %s, want %s", span.Name, span.SpanContext.TraceID(), traceID)
+		}
+	}
+}
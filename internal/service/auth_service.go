@@ -1,9 +1,14 @@
 package service
 
 import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
 	"Bookstore/internal/models"
 	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
 	"Bookstore/internal/wrong"
+	"context"
 	"errors"
 	"strconv"
 
@@ -12,178 +17,272 @@ import (
 )
 
 type AuthServ interface {
-	RegisterUser(user *models.User) error
-	Login(username, password string) (*models.User, error)
-	GetAllUsers() ([]*models.User, error)
-	GetUserByName(username string) (*models.User, error)
-	GetByUserID(id int64) (*models.User, error)
-	UpdateUser(user *models.User) error
-	DeleteUser(user *models.User) error
+	RegisterUser(ctx context.Context, user *models.User) error
+	Login(ctx context.Context, username, password string) (*models.User, error)
+	GetAllUsers(ctx context.Context) ([]*models.User, error)
+	GetUserByName(ctx context.Context, username string) (*models.User, error)
+	GetByUserID(ctx context.Context, id int) (*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	PatchUser(ctx context.Context, id, version int, patch []byte) (*models.User, error)
+	SetUserRole(ctx context.Context, username, role string) (*models.User, error)
+	DeleteUser(ctx context.Context, user *models.User) error
 }
 
 type AuthService struct {
 	UserRepo *repository.UserRepository
-	Log      *zap.Logger
+
+	logins       metrics.Counter
+	loginsFailed metrics.Counter
 }
 
-var ErrUserNotFound = errors.New("user not found")
+// ErrUserNotFound оставлен для совместимости, это тот же wrong.ErrUserNotFound
+var ErrUserNotFound = wrong.ErrUserNotFound
 
-func NewUserService(userRepo *repository.UserRepository, logger *zap.Logger) *AuthService {
+func NewUserService(userRepo *repository.UserRepository, reg metrics.Registry) *AuthService {
 	return &AuthService{
-		UserRepo: userRepo,
-		Log:      logger,
+		UserRepo:     userRepo,
+		logins:       reg.Counter("logins_total", "Successful logins."),
+		loginsFailed: reg.Counter("login_failures_total", "Failed logins by reason.", "reason"),
 	}
 }
 
-func (s *AuthService) RegisterUser(user *models.User) error {
-	if err := s.validateUserFields(user); err != nil {
-		s.Log.Warn("Ошибка валидации при создании пользователя", zap.Error(err))
-		return err
-	}
+func (s *AuthService) RegisterUser(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RegisterUser")
+	defer tracing.End(span, &err)
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		zap.L().Error("Error hashing password", zap.Error(err))
+	if err := s.validateUserFields(user); err != nil {
+		logging.FromContext(ctx).Warn("Validation failed while creating user", zap.Error(err))
 		return err
 	}
-	user.Password = string(hashedPassword)
 
-	if err := s.UserRepo.CreateUser(user); err != nil {
-		zap.L().Error("Error creating user", zap.String("username", user.Username), zap.Error(err))
+	// Пароль хешируется в репозитории, повторное хеширование ломает Login
+	if err := s.UserRepo.CreateUser(ctx, user); err != nil {
+		logging.FromContext(ctx).Error("Error creating user", zap.String("username", user.Username), zap.Error(err))
 		return err
 	}
 
-	zap.L().Info("User successfully registered", zap.String("username", user.Username))
+	logging.FromContext(ctx).Info("User successfully registered", zap.String("username", user.Username))
 	return nil
 }
 
-func (s *AuthService) Login(username, password string) (*models.User, error) {
-	user, err := s.UserRepo.GetUserByUsername(username)
+func (s *AuthService) Login(ctx context.Context, username, password string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer tracing.End(span, &err)
+
+	user, err := s.UserRepo.GetUserByUsername(ctx, username)
 	if err != nil {
-		zap.L().Error("Error fetching user by username", zap.String("username", username), zap.Error(err))
+		if errors.Is(err, wrong.ErrUserNotFound) {
+			logging.FromContext(ctx).Warn("Login for unknown user", zap.String("username", username))
+			s.loginsFailed.Inc("unknown_user")
+			// Не сообщаем клиенту, что пользователя нет — тот же ответ, что и на неверный пароль
+			return nil, wrong.ErrBadCredentials
+		}
+		logging.FromContext(ctx).Error("Error fetching user by username", zap.String("username", username), zap.Error(err))
 		return nil, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		zap.L().Warn("Invalid credentials", zap.String("username", username))
-		return nil, errors.New("invalid credentials")
+		logging.FromContext(ctx).Warn("Invalid credentials", zap.String("username", username))
+		s.loginsFailed.Inc("invalid_password")
+		return nil, wrong.ErrBadCredentials
 	}
 
-	zap.L().Info("User logged in successfully", zap.String("username", username))
+	logging.FromContext(ctx).Info("User logged in successfully", zap.String("username", username))
+	s.logins.Inc()
 	return user, nil
 }
 
-func (s *AuthService) GetAllUsers() ([]*models.User, error) {
-	users, err := s.UserRepo.GetAllUsers()
+func (s *AuthService) GetAllUsers(ctx context.Context) (_ []*models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetAllUsers")
+	defer tracing.End(span, &err)
+
+	users, err := s.UserRepo.GetAllUsers(ctx)
 	if err != nil {
-		zap.L().Error("Error fetching all users", zap.Error(err))
+		logging.FromContext(ctx).Error("Error fetching all users", zap.Error(err))
 		return nil, err
 	}
-	zap.L().Info("Fetched all users successfully")
+	logging.FromContext(ctx).Info("Fetched all users successfully")
 	return users, nil
 }
 
-func (s *AuthService) GetUserByName(username string) (*models.User, error) {
+func (s *AuthService) GetUserByName(ctx context.Context, username string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetUserByName")
+	defer tracing.End(span, &err)
+
 	if username == "" {
-		s.Log.Warn("Попытка получить пользователя с пустым именем")
+		logging.FromContext(ctx).Warn("Attempt to get user with empty username")
 		return nil, wrong.ErrEmptyUsername
 	}
 
-	user, err := s.UserRepo.GetUserByUsername(username)
+	user, err := s.UserRepo.GetUserByUsername(ctx, username)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			zap.L().Warn("User not found", zap.String("username", username))
+			logging.FromContext(ctx).Warn("User not found", zap.String("username", username))
 			return nil, ErrUserNotFound
 		}
-		zap.L().Error("Error fetching user by username", zap.String("username", username), zap.Error(err))
+		logging.FromContext(ctx).Error("Error fetching user by username", zap.String("username", username), zap.Error(err))
 		return nil, err
 	}
 
-	zap.L().Info("Fetched user successfully", zap.String("username", username))
+	logging.FromContext(ctx).Info("Fetched user successfully", zap.String("username", username))
 	return user, nil
 }
 
-func (s *AuthService) GetByUserID(id int) (*models.User, error) {
+func (s *AuthService) GetByUserID(ctx context.Context, id int) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetByUserID")
+	defer tracing.End(span, &err)
+
 	if id <= 0 {
-		s.Log.Warn("Trying to get with empty user ID")
+		logging.FromContext(ctx).Warn("Trying to get with empty user ID")
 		return nil, wrong.ErrUserIDZero
 	}
 
-	user, err := s.UserRepo.GetUserByID(id)
+	user, err := s.UserRepo.GetUserByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			zap.L().Warn("User not found", zap.Int("id", id))
+			logging.FromContext(ctx).Warn("User not found", zap.Int("id", id))
 			return nil, ErrUserNotFound
 		}
-		zap.L().Error("Error fetching user by ID", zap.Int("id", id), zap.Error(err))
+		logging.FromContext(ctx).Error("Error fetching user by ID", zap.Int("id", id), zap.Error(err))
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *AuthService) UpdateUser(user *models.User) error {
+func (s *AuthService) UpdateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.UpdateUser")
+	defer tracing.End(span, &err)
+
 	if err := s.validateUpdateFields(user); err != nil {
-		s.Log.Warn("Ошибка валидации при обновлении пользователя", zap.Error(err))
+		logging.FromContext(ctx).Warn("Validation failed while updating user", zap.Error(err))
 		return err
 	}
 
-	err := s.UserRepo.UpdateUser(user)
+	err = s.UserRepo.UpdateUser(ctx, user)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			zap.L().Warn("User not found for update", zap.String("username", user.Username))
+			logging.FromContext(ctx).Warn("User not found for update", zap.String("username", user.Username))
 			return ErrUserNotFound
 		}
-		zap.L().Error("Error updating user", zap.String("username", user.Username), zap.Error(err))
+		if errors.Is(err, wrong.ErrUserVersionMismatch) {
+			logging.FromContext(ctx).Warn("User version mismatch", zap.Int("id", user.ID), zap.Int("version", user.Version))
+			return err
+		}
+		logging.FromContext(ctx).Error("Error updating user", zap.String("username", user.Username), zap.Error(err))
 		return err
 	}
 
-	zap.L().Info("User updated successfully", zap.String("username", user.Username))
+	logging.FromContext(ctx).Info("User updated successfully", zap.String("username", user.Username))
 	return nil
 }
 
-func (s *AuthService) DeleteUser(user *models.User) error {
+// PatchUser частичное обновление по RFC 7396: меняются только присланные username, password и role;
+// version — ожидаемая версия пользователя, 0 — без проверки
+func (s *AuthService) PatchUser(ctx context.Context, id, version int, patch []byte) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.PatchUser")
+	defer tracing.End(span, &err)
 
-	if user.ID <= 0 {
-		zap.L().Warn("Invalid user ID for deletion")
-		return errors.New("invalid user ID")
+	current, err := s.GetByUserID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	current.Password = "" // хеш не участвует в патче
 
-	err := s.UserRepo.DeleteUser(user.ID)
+	user := &models.User{}
+	fields, err := applyMergePatch(current, patch, user)
 	if err != nil {
-		zap.L().Error("Error deleting user", zap.String("user ID", strconv.Itoa(user.ID)), zap.Error(err))
-		return err
+		logging.FromContext(ctx).Warn("Error applying user patch", zap.Error(err))
+		return nil, err
 	}
+	user.ID = id
+	user.Version = version // версию задаёт только If-Match, поле version в патче игнорируется
 
-	zap.L().Info("User deleted successfully", zap.String("username", user.Username))
-	return nil
+	// В патче пустой пароль — ошибка, а не «оставить прежний», как в PUT
+	if patched(fields, "password") && user.Password == "" {
+		return nil, wrong.ErrEmptyPassword
+	}
+	if err := s.validateUpdateFields(user); err != nil {
+		logging.FromContext(ctx).Warn("Validation failed while patching user", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.UserRepo.PatchUser(ctx, user, fields); err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	logging.FromContext(ctx).Info("User patched successfully", zap.Int("id", id), zap.Strings("fields", fields))
+	return user, nil
 }
 
-// Вспомогательная функция для валидации полей пользователя при создании
-func (s *AuthService) validateUserFields(user *models.User) error {
-	if user.Username == "" {
-		return wrong.ErrEmptyUsername
+// SetUserRole меняет роль пользователя, не трогая пароль
+func (s *AuthService) SetUserRole(ctx context.Context, username, role string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.SetUserRole")
+	defer tracing.End(span, &err)
+
+	if !models.IsValidRole(role) {
+		logging.FromContext(ctx).Warn("Invalid role", zap.String("role", role))
+		return nil, wrong.ErrInvalidRole
 	}
-	if user.Password == "" {
-		return wrong.ErrEmptyPassword
+
+	user, err := s.GetUserByName(ctx, username)
+	if err != nil {
+		return nil, err
 	}
-	if user.Role == "" {
-		return wrong.ErrEmptyRole
+
+	user.Role = role
+	user.Password = "" // пустой пароль — UpdateUser оставит текущий хеш
+	if err := s.UpdateUser(ctx, user); err != nil {
+		return nil, err
 	}
-	return nil
+	return user, nil
 }
 
-// Валидация полей при обновлении пользователя
-func (s *AuthService) validateUpdateFields(user *models.User) error {
-	if user.ID == 0 {
+func (s *AuthService) DeleteUser(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.DeleteUser")
+	defer tracing.End(span, &err)
+
+	if user.ID <= 0 {
+		logging.FromContext(ctx).Warn("Invalid user ID for deletion")
 		return wrong.ErrUserIDZero
 	}
-	if user.Username == "" {
-		return wrong.ErrEmptyUsername
-	}
-	if user.Role == "" {
-		return wrong.ErrEmptyRole
+
+	err = s.UserRepo.DeleteUser(ctx, user.ID, user.Version)
+	if err != nil {
+		if errors.Is(err, wrong.ErrUserVersionMismatch) {
+			logging.FromContext(ctx).Warn("User version mismatch", zap.Int("id", user.ID), zap.Int("version", user.Version))
+			return err
+		}
+		logging.FromContext(ctx).Error("Error deleting user", zap.String("user ID", strconv.Itoa(user.ID)), zap.Error(err))
+		return err
 	}
+
+	logging.FromContext(ctx).Info("User deleted successfully", zap.String("username", user.Username))
 	return nil
 }
+
+// registerRules правила для регистрации: пароль обязателен
+type registerRules struct {
+	Username string `json:"username" validate:"required,min=3,max=32,username"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
+	Role     string `json:"role" validate:"required,role"`
+}
+
+// updateRules правила для обновления: пустой пароль означает «не менять»
+type updateRules struct {
+	ID       int    `json:"id" validate:"gt=0"`
+	Username string `json:"username" validate:"required,min=3,max=32,username"`
+	Password string `json:"password" validate:"omitempty,min=6,max=72"`
+	Role     string `json:"role" validate:"required,role"`
+}
+
+// Вспомогательная функция для валидации полей пользователя при создании
+func (s *AuthService) validateUserFields(user *models.User) error {
+	return validation.Struct(registerRules{Username: user.Username, Password: user.Password, Role: user.Role})
+}
+
+// Валидация полей при обновлении пользователя
+func (s *AuthService) validateUpdateFields(user *models.User) error {
+	return validation.Struct(updateRules{ID: user.ID, Username: user.Username, Password: user.Password, Role: user.Role})
+}
@@ -0,0 +1,508 @@
+package service
+
+import (
+	"Bookstore/internal/actor"
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/sheet"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BookImportService импорт каталога из CSV/XLSX. Строки проверяются теми же правилами,
+// что и POST /admin/books, и сохраняются через BOokService, поэтому остаток попадает в журнал,
+// а версия книги растёт как при обычном обновлении
+type BookImportService interface {
+	Import(ctx context.Context, job *models.ImportJob, data []byte) (*models.ImportJob, error)
+	GetImportJob(ctx context.Context, id int) (*models.ImportJob, error)
+	RunNextImport(ctx context.Context, beat func()) (bool, error)
+}
+
+const (
+	// importBatch через сколько строк воркер сохраняет прогресс
+	importBatch = 200
+	// importSaveEvery прогресс сохраняется и по времени, даже если importBatch строк ещё не набралось:
+	// сохранение обновляет updated_at, и медленную задачу не примут за брошенную
+	importSaveEvery = 30 * time.Second
+	// importStaleAfter задачу running, прогресс которой не сохранялся так долго, подхватывает другой воркер;
+	// должно быть намного больше importSaveEvery
+	importStaleAfter = 5 * time.Minute
+)
+
+// importFields поля книги, которые можно загрузить из файла
+var importFields = []string{"id", "isbn", "title", "subtitle", "description", "author", "price", "quantity",
+	"original_language", "translation_of", "reorder_point", "reorder_quantity"}
+
+type bookImportService struct {
+	books    BOokService
+	repo     repository.ImportJobRepository
+	syncRows int
+
+	rows metrics.Counter
+}
+
+// NewBookImportService файлы не длиннее syncRows строк обрабатываются прямо в запросе, остальные — воркером
+func NewBookImportService(books BOokService, repo repository.ImportJobRepository, reg metrics.Registry, syncRows int) BookImportService {
+	return &bookImportService{
+		books:    books,
+		repo:     repo,
+		syncRows: syncRows,
+		rows:     reg.Counter("book_import_rows_total", "Rows of book import files by outcome (dry runs excluded).", "outcome"),
+	}
+}
+
+// Import проверяет файл и сопоставление колонок; маленький файл обрабатывается сразу
+// (задача возвращается в статусе done), большой ставится в очередь (queued)
+func (s *bookImportService) Import(ctx context.Context, job *models.ImportJob, data []byte) (_ *models.ImportJob, err error) {
+	ctx, span := tracing.Start(ctx, "BookImportService.Import")
+	defer tracing.End(span, &err)
+
+	if job.Match == "" {
+		job.Match = models.ImportMatchISBN
+	}
+	if err := validation.Struct(job); err != nil {
+		return nil, err
+	}
+	if job.TotalRows, err = countImportRows(job, data); err != nil {
+		logging.FromContext(ctx).Warn("Import file rejected", zap.String("format", job.Format), zap.Error(err))
+		return nil, err
+	}
+	job.Errors = []models.ImportRowError{}
+
+	if job.TotalRows > s.syncRows {
+		if err := s.repo.CreateImportJob(ctx, job, data); err != nil {
+			return nil, err
+		}
+		logging.FromContext(ctx).Info("Book import queued", zap.Int("job", job.ID), zap.Int("rows", job.TotalRows))
+		return job, nil
+	}
+
+	job.Status, job.CreatedBy, job.CreatedAt = models.ImportRunning, actor.From(ctx), time.Now()
+	if _, err := s.process(ctx, job, data, nil, nil); err != nil {
+		return nil, err
+	}
+	finishedAt := time.Now()
+	job.Status, job.FinishedAt = models.ImportDone, &finishedAt
+	return job, nil
+}
+
+func (s *bookImportService) GetImportJob(ctx context.Context, id int) (_ *models.ImportJob, err error) {
+	ctx, span := tracing.Start(ctx, "BookImportService.GetImportJob")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidImportJobID
+	}
+	return s.repo.GetImportJob(ctx, id)
+}
+
+// RunNextImport обрабатывает одну задачу из очереди; false — очередь пуста. beat вызывается
+// после каждой строки. Если обработка прервалась (остановка, сбой базы), задача
+// остаётся running и продолжится с последней сохранённой строки
+func (s *bookImportService) RunNextImport(ctx context.Context, beat func()) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "BookImportService.RunNextImport")
+	defer tracing.End(span, &err)
+
+	job, data, err := s.repo.ClaimImportJob(ctx, importStaleAfter)
+	if err != nil || job == nil {
+		return false, err
+	}
+	// Изменения книг в журнале записываются на того, кто загрузил файл
+	ctx = actor.With(ctx, job.CreatedBy)
+	logger := logging.FromContext(ctx).With(zap.Int("job", job.ID))
+	logger.Info("Book import started", zap.Int("rows", job.TotalRows), zap.Int("resume_from", job.ProcessedRows))
+
+	unsaved, err := s.process(ctx, job, data, beat, func(rowErrors []models.ImportRowError) error {
+		return s.repo.SaveImportProgress(ctx, job, rowErrors)
+	})
+	job.Status = models.ImportDone
+	if err != nil {
+		var e *wrong.Error
+		if ctx.Err() != nil || !errors.As(err, &e) || e.Status >= 500 {
+			return true, err
+		}
+		// Файл проверялся при загрузке, сюда попадаем, только если он всё-таки не читается
+		job.Status, job.Error = models.ImportFailed, e.Message
+	}
+
+	if err := s.repo.FinishImportJob(ctx, job, unsaved); err != nil {
+		return true, err
+	}
+	logger.Info("Book import finished", zap.String("status", job.Status), zap.Int("created", job.Created),
+		zap.Int("updated", job.Updated), zap.Int("failed", job.Failed))
+	return true, nil
+}
+
+// countImportRows читает файл целиком: проверяет заголовок, сопоставление и что каждая строка разбирается
+func countImportRows(job *models.ImportJob, data []byte) (int, error) {
+	r, _, err := openImport(job, data)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return total, nil
+		}
+		if err != nil {
+			return 0, wrong.ErrImportFileInvalid.Wrap(err)
+		}
+		if !blankRecord(record) {
+			total++
+		}
+	}
+}
+
+// openImport открывает файл и читает заголовок; columns — поле книги -> номер колонки
+func openImport(job *models.ImportJob, data []byte) (sheet.Reader, map[string]int, error) {
+	r, err := sheet.Open(job.Format, data)
+	if err != nil {
+		return nil, nil, wrong.ErrImportFileInvalid.Wrap(err)
+	}
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) || (err == nil && blankRecord(header)) {
+		return nil, nil, wrong.ErrImportNoHeader
+	}
+	if err != nil {
+		return nil, nil, wrong.ErrImportFileInvalid.Wrap(err)
+	}
+	columns, err := importColumns(header, job.Mapping, job.Match)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, columns, nil
+}
+
+// importColumns колонка для поля — из mapping, иначе колонка с тем же именем, что и поле
+// (без учёта регистра, пробелы и дефисы считаются подчёркиванием)
+func importColumns(header []string, mapping map[string]string, match string) (map[string]int, error) {
+	byName := make(map[string]int, len(header))
+	for i, name := range header {
+		if key := columnKey(name); key != "" {
+			if _, ok := byName[key]; !ok {
+				byName[key] = i
+			}
+		}
+	}
+
+	var fieldErrors []wrong.FieldError
+	columns := map[string]int{}
+	for _, field := range importFields {
+		if i, ok := byName[field]; ok {
+			columns[field] = i
+		}
+	}
+	fields := make([]string, 0, len(mapping))
+	for field := range mapping {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		name := mapping[field]
+		if !slices.Contains(importFields, field) {
+			fieldErrors = append(fieldErrors, wrong.FieldError{Field: "mapping." + field, Code: "oneof",
+				Param: strings.Join(importFields, " "), Message: "mapping." + field + " must be one of: " + strings.Join(importFields, ", ")})
+			continue
+		}
+		i, ok := byName[columnKey(name)]
+		if !ok {
+			fieldErrors = append(fieldErrors, withField(wrong.ErrImportColumn, "mapping."+field, name))
+			continue
+		}
+		columns[field] = i
+	}
+	if _, ok := columns[match]; !ok && len(fieldErrors) == 0 {
+		fieldErrors = append(fieldErrors, withField(wrong.ErrImportColumn, "match", match))
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, wrong.Validation(fieldErrors...)
+	}
+	return columns, nil
+}
+
+func columnKey(name string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func blankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// withField ошибка поля из sentinel (для кода правила и сообщения) с другим полем и параметром
+func withField(sentinel *wrong.Error, field, param string) wrong.FieldError {
+	fe := sentinel.Fields[0]
+	fe.Field, fe.Param = field, param
+	if param != "" {
+		fe.Message += ": " + param
+	}
+	return fe
+}
+
+// process обрабатывает строки файла после первых job.ProcessedRows (они уже обработаны раньше).
+// beat (если задан) вызывается после каждой строки, flush — каждые importBatch строк или importSaveEvery
+// с ошибками строк, ещё не переданными в flush; возвращаются ошибки строк после последнего flush
+func (s *bookImportService) process(ctx context.Context, job *models.ImportJob, data []byte, beat func(), flush func([]models.ImportRowError) error) ([]models.ImportRowError, error) {
+	r, columns, err := openImport(job, data)
+	if err != nil {
+		return nil, err
+	}
+
+	done := job.ProcessedRows
+	saved := len(job.Errors)
+	seen := map[string]bool{}
+	n := 0
+	flushed := time.Now()
+	for row := 2; ; row++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return job.Errors[saved:], nil
+		}
+		if err != nil {
+			return nil, wrong.ErrImportFileInvalid.Wrap(err)
+		}
+		if blankRecord(record) {
+			continue
+		}
+		values := rowValues(record, columns)
+		if n++; n <= done {
+			// Повторы ключа считаются и среди строк, обработанных до перезапуска
+			seen[importKey(job.Match, values)] = true
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		outcome, rowErr := s.importRow(ctx, job, row, values, seen)
+		if rowErr != nil {
+			if e := wrong.From(rowErr); e.Status >= 500 {
+				logging.FromContext(ctx).Error("Book import stopped", zap.Int("row", row), zap.Error(rowErr))
+				return nil, rowErr
+			}
+			job.Errors = append(job.Errors, importRowError(row, importKey(job.Match, values), rowErr))
+		}
+		switch outcome {
+		case "created":
+			job.Created++
+		case "updated":
+			job.Updated++
+		default:
+			job.Failed++
+		}
+		if !job.DryRun {
+			s.rows.Inc(outcome)
+		}
+		job.ProcessedRows++
+		if beat != nil {
+			beat()
+		}
+
+		if flush != nil && (job.ProcessedRows%importBatch == 0 || time.Since(flushed) >= importSaveEvery) {
+			if err := flush(job.Errors[saved:]); err != nil {
+				return nil, err
+			}
+			saved, flushed = len(job.Errors), time.Now()
+		}
+	}
+}
+
+// importRow создаёт или обновляет одну книгу; outcome — created, updated или failed.
+// В dry run строка только проверяется: книга ищется и валидируется, но не сохраняется
+func (s *bookImportService) importRow(ctx context.Context, job *models.ImportJob, row int, values map[string]string, seen map[string]bool) (string, error) {
+	key := importKey(job.Match, values)
+	if key == "" {
+		return "failed", wrong.Validation(wrong.FieldError{Field: job.Match, Code: "required", Message: job.Match + " is required"})
+	}
+	if seen[key] {
+		return "failed", wrong.Validation(withField(wrong.ErrImportDuplicate, job.Match, key))
+	}
+	seen[key] = true
+
+	existing, err := s.findBook(ctx, job.Match, key)
+	if err != nil && !errors.Is(err, wrong.ErrBookNotFound) {
+		return "failed", err
+	}
+
+	book := &models.Book{}
+	outcome := "created"
+	if existing != nil {
+		*book = *existing
+		outcome = "updated"
+		// Подзаголовок и описание хранятся в переводе на языке оригинала
+		if original, ok := book.Translation(book.OriginalLanguage); ok {
+			book.Subtitle, book.Description = original.Subtitle, original.Description
+		}
+	}
+	if fieldErrors := applyImportValues(book, values); len(fieldErrors) > 0 {
+		return "failed", wrong.Validation(append(fieldErrors, otherFieldErrors(book, fieldErrors)...)...)
+	}
+
+	if existing != nil {
+		if book.ID != existing.ID {
+			return "failed", wrong.Validation(withField(wrong.ErrImportKeyMismatch, "id", job.Match))
+		}
+		setOriginalTranslation(book)
+		book.Version = existing.Version
+	}
+
+	// Новая книга из файла без колонки id получает следующий свободный ID, как из ONIX
+	if outcome == "created" && book.ID == 0 {
+		if err := createWithNextID(ctx, s.books, book, job.DryRun); err != nil {
+			return "failed", err
+		}
+		return outcome, nil
+	}
+
+	if job.DryRun {
+		normalizeTranslations(book)
+		if err := validateBookFields(book); err != nil {
+			return "failed", err
+		}
+		if outcome == "created" {
+			if _, err := s.books.GetBookByID(ctx, book.ID); err == nil {
+				return "failed", wrong.ErrBookExists
+			} else if !errors.Is(err, wrong.ErrBookNotFound) {
+				return "failed", err
+			}
+		}
+		return outcome, nil
+	}
+
+	if outcome == "created" {
+		err = s.books.CreateBook(ctx, book)
+	} else {
+		err = s.books.UpdateBook(ctx, book)
+	}
+	if err != nil {
+		return "failed", err
+	}
+	return outcome, nil
+}
+
+func (s *bookImportService) findBook(ctx context.Context, match, key string) (*models.Book, error) {
+	if match == models.ImportMatchISBN {
+		return s.books.GetBookByISBN(ctx, key)
+	}
+	id, err := strconv.Atoi(key)
+	if err != nil {
+		return nil, validation.TypeMismatch("id", "integer")
+	}
+	return s.books.GetBookByID(ctx, id)
+}
+
+// rowValues непустые значения строки по полям книги
+func rowValues(record []string, columns map[string]int) map[string]string {
+	values := make(map[string]string, len(columns))
+	for field, i := range columns {
+		if i < len(record) {
+			if v := strings.TrimSpace(record[i]); v != "" {
+				values[field] = v
+			}
+		}
+	}
+	return values
+}
+
+// importKey значение поля, по которому ищется книга; ISBN приводится к ISBN-13
+func importKey(match string, values map[string]string) string {
+	if match == models.ImportMatchISBN {
+		return models.NormalizeISBN(values["isbn"])
+	}
+	return values[match]
+}
+
+// applyImportValues переносит значения строки в книгу; пустые ячейки не меняют поле
+func applyImportValues(book *models.Book, values map[string]string) []wrong.FieldError {
+	var fieldErrors []wrong.FieldError
+	integer := func(field string, dst *int) {
+		if v, ok := values[field]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				fieldErrors = append(fieldErrors, validation.TypeMismatch(field, "integer").Fields...)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	integer("id", &book.ID)
+	integer("quantity", &book.Quantity)
+	integer("reorder_point", &book.ReorderPoint)
+	integer("reorder_quantity", &book.ReorderQuantity)
+	if _, ok := values["translation_of"]; ok {
+		var id int
+		integer("translation_of", &id)
+		book.TranslationOf = &id
+	}
+	if v, ok := values["price"]; ok {
+		// Десятичная запятая — частый случай в CSV из Excel с русской локалью
+		price, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+		if err != nil {
+			fieldErrors = append(fieldErrors, validation.TypeMismatch("price", "number").Fields...)
+		} else {
+			book.Price = price
+		}
+	}
+
+	strs := map[string]*string{
+		"isbn":              &book.ISBN,
+		"title":             &book.Title,
+		"subtitle":          &book.Subtitle,
+		"description":       &book.Description,
+		"author":            &book.Author,
+		"original_language": &book.OriginalLanguage,
+	}
+	for field, dst := range strs {
+		if v, ok := values[field]; ok {
+			*dst = v
+		}
+	}
+	return fieldErrors
+}
+
+// otherFieldErrors ошибки валидации книги в полях, которые ещё не попали в fieldErrors,
+// чтобы отчёт по строке был полным, даже если часть значений не разобралась
+func otherFieldErrors(book *models.Book, fieldErrors []wrong.FieldError) []wrong.FieldError {
+	c := *book
+	if c.ID == 0 {
+		// ID новой книге без колонки id назначит createWithNextID
+		c.ID = 1
+	}
+	normalizeTranslations(&c)
+	var e *wrong.Error
+	if err := validateBookFields(&c); !errors.As(err, &e) {
+		return nil
+	}
+	var out []wrong.FieldError
+	for _, fe := range e.Fields {
+		if !slices.ContainsFunc(fieldErrors, func(f wrong.FieldError) bool { return f.Field == fe.Field }) {
+			out = append(out, fe)
+		}
+	}
+	return out
+}
+
+// importRowError ошибка строки для отчёта: ошибки полей или код ошибки целой строки
+func importRowError(row int, key string, err error) models.ImportRowError {
+	e := wrong.From(err)
+	return models.ImportRowError{Row: row, Key: key, Code: e.Code, Message: e.Message, Errors: e.Fields}
+}
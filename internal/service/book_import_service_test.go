@@ -0,0 +1,134 @@
+package service
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestImportColumns колонки находятся по имени поля без учёта регистра, пробелов и дефисов;
+// mapping важнее совпадения имён, первая из одноимённых колонок выигрывает
+func TestImportColumns(t *testing.T) {
+	header := []string{" ISBN ", "Title", "Author Name", "Price", "reorder-point", "title", "Notes"}
+	for _, tc := range []struct {
+		name    string
+		mapping map[string]string
+		match   string
+		want    map[string]int
+	}{
+		{
+			name:  "by name",
+			match: models.ImportMatchISBN,
+			want:  map[string]int{"isbn": 0, "title": 1, "price": 3, "reorder_point": 4},
+		},
+		{
+			name:    "mapping",
+			mapping: map[string]string{"author": "author name", "description": "NOTES"},
+			match:   models.ImportMatchISBN,
+			want:    map[string]int{"isbn": 0, "title": 1, "author": 2, "price": 3, "reorder_point": 4, "description": 6},
+		},
+		{
+			name:    "mapping overrides the same name",
+			mapping: map[string]string{"title": "Notes"},
+			match:   models.ImportMatchISBN,
+			want:    map[string]int{"isbn": 0, "title": 6, "price": 3, "reorder_point": 4},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := importColumns(header, tc.mapping, tc.match)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("columns = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestImportColumnsInvalid все ошибки сопоставления сразу; колонка ключа проверяется, только если mapping верен
+func TestImportColumnsInvalid(t *testing.T) {
+	header := []string{"isbn", "title"}
+	for _, tc := range []struct {
+		name    string
+		mapping map[string]string
+		match   string
+		want    []wrong.FieldError
+	}{
+		{
+			name:    "unknown field and column",
+			mapping: map[string]string{"available": "title", "author": "writer"},
+			match:   models.ImportMatchISBN,
+			want:    []wrong.FieldError{{Field: "mapping.author", Code: "column"}, {Field: "mapping.available", Code: "oneof"}},
+		},
+		{
+			name:  "no key column",
+			match: models.ImportMatchID,
+			want:  []wrong.FieldError{{Field: "match", Code: "column"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := importColumns(header, tc.mapping, tc.match)
+			var e *wrong.Error
+			if !errors.As(err, &e) || e.Code != wrong.CodeValidation {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+			if len(e.Fields) != len(tc.want) {
+				t.Fatalf("fields = %+v, want %+v", e.Fields, tc.want)
+			}
+			for i, want := range tc.want {
+				if got := e.Fields[i]; got.Field != want.Field || got.Code != want.Code {
+					t.Errorf("field %d = %s/%s, want %s/%s", i, got.Field, got.Code, want.Field, want.Code)
+				}
+			}
+		})
+	}
+}
+
+// TestApplyImportValues значения разбираются по типам полей, пустые ячейки оставляют поле как было
+func TestApplyImportValues(t *testing.T) {
+	book := &models.Book{ID: 3, Title: "Old", Author: "Author", Price: 5, Quantity: 2, Description: "kept"}
+	fieldErrors := applyImportValues(book, map[string]string{
+		"isbn":              "9780306406157",
+		"title":             "Dune",
+		"price":             "12,50",
+		"quantity":          "7",
+		"reorder_point":     "3",
+		"reorder_quantity":  "10",
+		"translation_of":    "1",
+		"original_language": "en",
+	})
+	if len(fieldErrors) != 0 {
+		t.Fatalf("unexpected errors: %+v", fieldErrors)
+	}
+	translationOf := 1
+	want := &models.Book{ID: 3, ISBN: "9780306406157", Title: "Dune", Author: "Author", Description: "kept", Price: 12.5,
+		Quantity: 7, ReorderPoint: 3, ReorderQuantity: 10, TranslationOf: &translationOf, OriginalLanguage: "en"}
+	if !reflect.DeepEqual(book, want) {
+		t.Errorf("book = %+v\nwant   %+v", book, want)
+	}
+}
+
+// TestApplyImportValuesTypes каждое неразобранное значение — своя ошибка поля, остальные поля переносятся
+func TestApplyImportValuesTypes(t *testing.T) {
+	book := &models.Book{Quantity: 2}
+	fieldErrors := applyImportValues(book, map[string]string{
+		"id":       "abc",
+		"quantity": "1.5",
+		"price":    "free",
+		"title":    "Dune",
+	})
+	got := map[string]string{}
+	for _, fe := range fieldErrors {
+		got[fe.Field] = fe.Code + ":" + fe.Param
+	}
+	want := map[string]string{"id": "type:integer", "quantity": "type:integer", "price": "type:number"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors = %v, want %v", got, want)
+	}
+	if book.Title != "Dune" || book.Quantity != 2 {
+		t.Errorf("book = %+v, want title set and quantity unchanged", book)
+	}
+}
@@ -1,91 +1,292 @@
 package service
 
 import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
 	"Bookstore/internal/models"
 	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
 	"Bookstore/internal/wrong"
+	"context"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"strconv"
+	"strings"
 )
 
 type BOokService interface {
-	CreateBook(book *models.Book) error
-	GetBookByID(id int) (*models.Book, error)
-	GetAllBook() ([]*models.Book, error)
-	UpdateBook(book *models.Book) error
-	DeleteBook(id int) error
+	CreateBook(ctx context.Context, book *models.Book) error
+	GetBookByID(ctx context.Context, id int) (*models.Book, error)
+	GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error)
+	NextBookID(ctx context.Context) (int, error)
+	SetSupplierAvailability(ctx context.Context, id int, availability *int) error
+	GetAllBook(ctx context.Context) ([]*models.Book, error)
+	SearchBooks(ctx context.Context, query string) ([]*models.Book, error)
+	ExportBooks(ctx context.Context, query string, fn func(book *models.Book) error) error
+	UpdateBook(ctx context.Context, book *models.Book) error
+	PatchBook(ctx context.Context, id, version int, patch []byte) (*models.Book, error)
+	DeleteBook(ctx context.Context, id, version int) error
 }
 
 type bookService struct {
 	repo repository.BookRepository
-	Log  *zap.Logger
+
+	booksCreated metrics.Counter
 }
 
-func NewBookService(repo repository.BookRepository, logger *zap.Logger) BOokService {
+func NewBookService(repo repository.BookRepository, reg metrics.Registry) BOokService {
 	return &bookService{
-		repo: repo,
-		Log:  logger,
+		repo:         repo,
+		booksCreated: reg.Counter("books_created_total", "Books added to the catalog."),
 	}
 }
 
-func (s *bookService) CreateBook(book *models.Book) error {
-	if err := s.validateBookFields(book); err != nil {
-		s.Log.Warn("Error validating book", zap.Error(err))
+func (s *bookService) CreateBook(ctx context.Context, book *models.Book) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.CreateBook")
+	defer tracing.End(span, &err)
+
+	normalizeTranslations(book)
+	if err := validateBookFields(book); err != nil {
+		logging.FromContext(ctx).Warn("Error validating book", zap.Error(err))
 		return err
 	}
-	err := s.repo.CreateBook(book)
+	err = s.repo.CreateBook(ctx, book)
 	if err != nil {
-		s.Log.Error("Failed to create book", zap.Error(err))
+		logging.FromContext(ctx).Error("Failed to create book", zap.Error(err))
 		return fmt.Errorf("could not save book: %w", err)
 	}
+	s.booksCreated.Inc()
 	return nil
 }
 
-func (s *bookService) GetBookByID(id int) (*models.Book, error) {
+func (s *bookService) GetBookByID(ctx context.Context, id int) (_ *models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBookByID")
+	defer tracing.End(span, &err)
+
 	if id <= 0 {
-		s.Log.Warn("your book id is empty")
+		logging.FromContext(ctx).Warn("your book id is empty")
 		return nil, wrong.ErrBookIDZero
 	}
 
-	return s.repo.GetBookByID(id)
+	return s.repo.GetBookByID(ctx, id)
+}
+
+// GetBookByISBN принимает ISBN-10 и ISBN-13, с дефисами или без
+func (s *bookService) GetBookByISBN(ctx context.Context, isbn string) (_ *models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBookByISBN")
+	defer tracing.End(span, &err)
+
+	isbn = models.NormalizeISBN(isbn)
+	if !models.ValidISBN13(isbn) {
+		return nil, wrong.Validation(wrong.FieldError{Field: "isbn", Code: "isbn", Message: "isbn must be a valid ISBN-10 or ISBN-13"})
+	}
+	return s.repo.GetBookByISBN(ctx, isbn)
+}
+
+// NextBookID ID для новой книги, если источник данных его не задаёт
+func (s *bookService) NextBookID(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.NextBookID")
+	defer tracing.End(span, &err)
+
+	return s.repo.NextBookID(ctx)
+}
+
+// SetSupplierAvailability остаток у издателя; остаток магазина и версия книги не меняются
+func (s *bookService) SetSupplierAvailability(ctx context.Context, id int, availability *int) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.SetSupplierAvailability")
+	defer tracing.End(span, &err)
+
+	return s.repo.SetSupplierAvailability(ctx, id, availability)
+}
+
+func (s *bookService) GetAllBook(ctx context.Context) (_ []*models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetAllBook")
+	defer tracing.End(span, &err)
+
+	return s.repo.GetAllBooks(ctx)
+}
+
+// SearchBooks полнотекстовый поиск по метаданным на всех языках; пустой запрос — весь каталог
+func (s *bookService) SearchBooks(ctx context.Context, query string) (_ []*models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.SearchBooks")
+	defer tracing.End(span, &err)
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return s.repo.GetAllBooks(ctx)
+	}
+	return s.repo.SearchBooks(ctx, query)
+}
+
+// ExportBooks как SearchBooks, но книги передаются в fn по одной, не собираясь в список
+func (s *bookService) ExportBooks(ctx context.Context, query string, fn func(book *models.Book) error) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.ExportBooks")
+	defer tracing.End(span, &err)
+
+	return s.repo.ExportBooks(ctx, strings.TrimSpace(query), fn)
 }
 
-func (s *bookService) GetAllBook() ([]*models.Book, error) {
-	return s.repo.GetAllBooks()
+// ExportColumns колонки выгрузки каталога в CSV и XLSX (HTTP и CLI): имена те же, что понимает импорт,
+// плюс язык, доступный остаток и закупочная цена (импорт их пропускает)
+var ExportColumns = []any{"id", "isbn", "title", "subtitle", "description", "language", "author", "price", "quantity",
+	"available", "original_language", "translation_of", "reorder_point", "reorder_quantity", "cost_price"}
+
+// ExportRecord строка книги для CSV и XLSX, значения в порядке ExportColumns
+func ExportRecord(book *models.Book) []any {
+	var available, translationOf, costPrice any
+	if book.Available != nil {
+		available = *book.Available
+	}
+	if book.TranslationOf != nil {
+		translationOf = *book.TranslationOf
+	}
+	if book.CostPrice != nil {
+		costPrice = *book.CostPrice
+	}
+	return []any{book.ID, book.ISBN, book.Title, book.Subtitle, book.Description, book.Language, book.Author,
+		book.Price, book.Quantity, available, book.OriginalLanguage, translationOf, book.ReorderPoint, book.ReorderQuantity, costPrice}
 }
 
-func (s *bookService) UpdateBook(book *models.Book) error {
+func (s *bookService) UpdateBook(ctx context.Context, book *models.Book) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.UpdateBook")
+	defer tracing.End(span, &err)
+
 	if book.ID == 0 {
-		s.Log.Error("Error when creating book", zap.String("id", strconv.Itoa(book.ID)), zap.Error(wrong.ErrBookIDZero))
+		logging.FromContext(ctx).Error("Error when creating book", zap.String("id", strconv.Itoa(book.ID)), zap.Error(wrong.ErrBookIDZero))
 		return wrong.ErrBookIDZero
 	}
-	return s.repo.Update(book)
+	normalizeTranslations(book)
+	if err := validateBookFields(book); err != nil {
+		logging.FromContext(ctx).Warn("Error validating book", zap.Error(err))
+		return err
+	}
+	return s.repo.Update(ctx, book)
 }
 
-func (s *bookService) DeleteBook(id int) error {
+// PatchBook частичное обновление по RFC 7396: в базе меняются только присланные поля.
+// version из If-Match; 0 — проверяется версия, прочитанная перед наложением патча
+func (s *bookService) PatchBook(ctx context.Context, id, version int, patch []byte) (_ *models.Book, err error) {
+	ctx, span := tracing.Start(ctx, "BookService.PatchBook")
+	defer tracing.End(span, &err)
+
 	if id <= 0 {
-		s.Log.Warn("your book id is empty")
-		return wrong.ErrBookIDZero
+		logging.FromContext(ctx).Warn("your book id is empty")
+		return nil, wrong.ErrBookIDZero
+	}
+
+	current, err := s.repo.GetBookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Подзаголовок и описание хранятся в переводе на языке оригинала
+	if original, ok := current.Translation(current.OriginalLanguage); ok {
+		current.Subtitle, current.Description = original.Subtitle, original.Description
+	}
+
+	book := &models.Book{}
+	fields, err := applyMergePatch(current, patch, book)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error applying book patch", zap.Error(err))
+		return nil, err
 	}
-	return s.repo.DeleteBook(id)
+	book.ID = id // ID из пути, поле id в патче игнорируется
+	book.Version = current.Version
+	if version > 0 {
+		book.Version = version
+	}
+
+	if !patched(fields, "translations") {
+		setOriginalTranslation(book)
+	}
+	normalizeTranslations(book)
+	if err := validateBookFields(book); err != nil {
+		logging.FromContext(ctx).Warn("Error validating book", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.repo.Patch(ctx, book, fields); err != nil {
+		return nil, err
+	}
+	return book, nil
 }
 
-func (s *bookService) validateBookFields(book *models.Book) error {
-	if book.ID <= 0 {
+func (s *bookService) DeleteBook(ctx context.Context, id, version int) (err error) {
+	ctx, span := tracing.Start(ctx, "BookService.DeleteBook")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		logging.FromContext(ctx).Warn("your book id is empty")
 		return wrong.ErrBookIDZero
 	}
-	if book.Title == "" {
-		return wrong.ErrEmptyTitle
+	return s.repo.DeleteBook(ctx, id, version)
+}
+
+// createAttempts сколько раз createWithNextID берёт новый ID, если его успели занять параллельно
+const createAttempts = 3
+
+// createWithNextID создаёт книгу со следующим свободным ID — для источников без ID (ONIX, импорт по ISBN).
+// В dryRun ID не выдаётся: для проверки подойдёт любой, настоящий появится только при сохранении
+func createWithNextID(ctx context.Context, books BOokService, book *models.Book, dryRun bool) error {
+	if dryRun {
+		book.ID = 1
+		normalizeTranslations(book)
+		return validateBookFields(book)
 	}
-	if book.Author == "" {
-		return wrong.ErrEmptyAuthor
+	var err error
+	for attempt := 0; attempt < createAttempts; attempt++ {
+		if book.ID, err = books.NextBookID(ctx); err != nil {
+			return err
+		}
+		if err = books.CreateBook(ctx, book); !errors.Is(err, wrong.ErrBookExists) {
+			return err
+		}
 	}
-	if book.Price <= 0 {
-		return wrong.ErrBookIDZero
+	return err
+}
+
+// normalizeTranslations язык оригинала по умолчанию — английский. Если переводы переданы,
+// среди них обязательно есть язык оригинала: его название и попадает в books.title
+func normalizeTranslations(book *models.Book) {
+	if book.OriginalLanguage == "" {
+		book.OriginalLanguage = models.DefaultBookLanguage
 	}
-	if book.Quantity <= 0 {
-		return wrong.ErrBookIDZero
+	if len(book.Translations) == 0 {
+		return
 	}
-	return nil
+	if original, ok := book.Translation(book.OriginalLanguage); ok {
+		book.Title, book.Subtitle, book.Description = original.Title, original.Subtitle, original.Description
+		return
+	}
+	book.Translations = append(book.Translations, models.BookTranslation{
+		Locale:      book.OriginalLanguage,
+		Title:       book.Title,
+		Subtitle:    book.Subtitle,
+		Description: book.Description,
+	})
+}
+
+// setOriginalTranslation переносит title, subtitle и description в перевод на языке оригинала
+func setOriginalTranslation(book *models.Book) {
+	original := models.BookTranslation{
+		Locale:      book.OriginalLanguage,
+		Title:       book.Title,
+		Subtitle:    book.Subtitle,
+		Description: book.Description,
+	}
+	for i, t := range book.Translations {
+		if t.Locale == original.Locale {
+			book.Translations[i] = original
+			return
+		}
+	}
+	book.Translations = append(book.Translations, original)
+}
+
+// validateBookFields правила заданы тегами validate в models.Book; возвращает все ошибки полей сразу.
+// ISBN перед проверкой приводится к ISBN-13, в базу он попадает уже в таком виде
+func validateBookFields(book *models.Book) error {
+	book.ISBN = models.NormalizeISBN(book.ISBN)
+	return validation.Struct(book)
 }
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"go.uber.org/zap"
+	"math"
+	"strings"
+)
+
+// Point координаты покупателя для поиска ближайшей точки
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// LocationService точки продаж и хранения и наличие книг по ним
+type LocationService interface {
+	CreateLocation(ctx context.Context, l *models.Location) error
+	GetAllLocations(ctx context.Context) ([]*models.Location, error)
+	Availability(ctx context.Context, bookID int, near *Point, quantity int) (*models.Availability, error)
+}
+
+type locationService struct {
+	repo repository.LocationRepository
+}
+
+func NewLocationService(repo repository.LocationRepository) LocationService {
+	return &locationService{repo: repo}
+}
+
+func (s *locationService) CreateLocation(ctx context.Context, l *models.Location) (err error) {
+	ctx, span := tracing.Start(ctx, "LocationService.CreateLocation")
+	defer tracing.End(span, &err)
+
+	l.Code = strings.ToLower(strings.TrimSpace(l.Code))
+	if err := validation.Struct(l); err != nil {
+		logging.FromContext(ctx).Warn("Error validating location", zap.Error(err))
+		return err
+	}
+	return s.repo.CreateLocation(ctx, l)
+}
+
+func (s *locationService) GetAllLocations(ctx context.Context) (_ []*models.Location, err error) {
+	ctx, span := tracing.Start(ctx, "LocationService.GetAllLocations")
+	defer tracing.End(span, &err)
+
+	return s.repo.GetAllLocations(ctx)
+}
+
+// Availability остатки книги по точкам и их сумма. Если передан near — у точек с координатами
+// считается расстояние, а в Nearest попадает ближайшая, где доступно хотя бы quantity экземпляров
+func (s *locationService) Availability(ctx context.Context, bookID int, near *Point, quantity int) (_ *models.Availability, err error) {
+	ctx, span := tracing.Start(ctx, "LocationService.Availability")
+	defer tracing.End(span, &err)
+
+	if bookID <= 0 {
+		return nil, wrong.ErrBookIDZero
+	}
+	levels, err := s.repo.StockLevels(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := &models.Availability{BookID: bookID, Locations: levels}
+	for i := range levels {
+		level := &levels[i]
+		availability.Total += level.Quantity
+		availability.Available += level.Available
+		if near == nil || level.Latitude == nil || level.Longitude == nil {
+			continue
+		}
+		distance := distanceKm(*near, Point{Latitude: *level.Latitude, Longitude: *level.Longitude})
+		level.DistanceKm = &distance
+		if level.Available >= quantity && (availability.Nearest == nil || distance < *availability.Nearest.DistanceKm) {
+			availability.Nearest = level
+		}
+	}
+	return availability, nil
+}
+
+// distanceKm расстояние по дуге большого круга (формула гаверсинусов), округлённое до 0.1 км
+func distanceKm(a, b Point) float64 {
+	const earthRadiusKm = 6371.0
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := rad(b.Latitude - a.Latitude)
+	dLon := rad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(a.Latitude))*math.Cos(rad(b.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	d := 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+	return math.Round(d*10) / 10
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/onix"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/wrong"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"math"
+	"strings"
+)
+
+// OnixService загрузка каталога из ONIX 3.0 фидов издателей. Записи сопоставляются с книгами
+// по ISBN и сохраняются через BOokService; фид читается потоково, по одной записи Product
+type OnixService interface {
+	Ingest(ctx context.Context, feed io.Reader, dryRun bool) (*models.OnixSummary, error)
+}
+
+// maxOnixErrors сколько ошибок записей попадает в отчёт
+const maxOnixErrors = 1000
+
+type onixService struct {
+	books    BOokService
+	currency string
+
+	products metrics.Counter
+}
+
+// NewOnixService currency — валюта, цена в которой берётся из фида; пустая — любая
+func NewOnixService(books BOokService, reg metrics.Registry, currency string) OnixService {
+	return &onixService{
+		books:    books,
+		currency: currency,
+		products: reg.Counter("onix_products_total", "ONIX product records by outcome (dry runs excluded).", "outcome"),
+	}
+}
+
+// Ingest применяет записи фида по порядку: 05 удаляет книгу, остальные создают или обновляют её.
+// Ошибка записи попадает в отчёт и не мешает остальным; если фид перестал разбираться посреди файла,
+// уже применённое остаётся, а причина — в summary.Error. Повторная загрузка фида ничего не меняет
+func (s *onixService) Ingest(ctx context.Context, feed io.Reader, dryRun bool) (_ *models.OnixSummary, err error) {
+	ctx, span := tracing.Start(ctx, "OnixService.Ingest")
+	defer tracing.End(span, &err)
+
+	logger := logging.FromContext(ctx)
+	summary := &models.OnixSummary{DryRun: dryRun, Errors: []models.ImportRowError{}}
+	r := onix.NewReader(feed)
+	for {
+		p, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if summary.Products == 0 {
+				logger.Warn("ONIX feed rejected", zap.Error(err))
+				return nil, wrong.ErrOnixInvalid.Wrap(err)
+			}
+			logger.Warn("ONIX feed stopped", zap.Int("products", summary.Products), zap.Error(err))
+			summary.Error = err.Error()
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		summary.Products++
+		outcome, err := s.apply(ctx, p, dryRun)
+		if err != nil {
+			if e := wrong.From(err); e.Status >= 500 {
+				logger.Error("ONIX feed stopped", zap.Int("product", summary.Products), zap.Error(err))
+				return nil, err
+			}
+			if len(summary.Errors) < maxOnixErrors {
+				key := p.ISBN
+				if key == "" {
+					key = p.RecordReference
+				}
+				summary.Errors = append(summary.Errors, importRowError(summary.Products, key, err))
+			} else {
+				summary.ErrorsTruncated = true
+			}
+		}
+		switch outcome {
+		case "created":
+			summary.Created++
+		case "updated":
+			summary.Updated++
+		case "deleted":
+			summary.Deleted++
+		case "skipped":
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+		if !dryRun {
+			s.products.Inc(outcome)
+		}
+	}
+
+	logger.Info("ONIX feed processed", zap.Bool("dry_run", dryRun), zap.Int("products", summary.Products),
+		zap.Int("created", summary.Created), zap.Int("updated", summary.Updated), zap.Int("deleted", summary.Deleted),
+		zap.Int("skipped", summary.Skipped), zap.Int("failed", summary.Failed))
+	return summary, nil
+}
+
+// apply одна запись; outcome — created, updated, deleted, skipped или failed
+func (s *onixService) apply(ctx context.Context, p *onix.Product, dryRun bool) (string, error) {
+	isbn := models.NormalizeISBN(p.ISBN)
+	if isbn == "" {
+		return "failed", wrong.Validation(wrong.FieldError{Field: "isbn", Code: "required", Message: "isbn is required"})
+	}
+	existing, err := s.books.GetBookByISBN(ctx, isbn)
+	if err != nil && !errors.Is(err, wrong.ErrBookNotFound) {
+		return "failed", err
+	}
+
+	if p.Deleted() {
+		if existing == nil {
+			return "skipped", nil
+		}
+		if !dryRun {
+			if err := s.books.DeleteBook(ctx, existing.ID, existing.Version); err != nil {
+				return "failed", err
+			}
+		}
+		return "deleted", nil
+	}
+
+	book := &models.Book{ISBN: isbn, OriginalLanguage: p.ShortLanguage()}
+	if existing != nil {
+		*book = *existing
+		// Подзаголовок и описание хранятся в переводе на языке оригинала
+		if original, ok := book.Translation(book.OriginalLanguage); ok {
+			book.Subtitle, book.Description = original.Subtitle, original.Description
+		}
+	}
+	before := *book
+	s.overlay(book, p)
+
+	availability := supplierAvailability(p)
+
+	if existing == nil {
+		// ID в ONIX нет, книга получает следующий свободный
+		if err := createWithNextID(ctx, s.books, book, dryRun); err != nil {
+			return "failed", err
+		}
+		if availability != nil && !dryRun {
+			if err := s.books.SetSupplierAvailability(ctx, book.ID, availability); err != nil {
+				return "failed", err
+			}
+		}
+		return "created", nil
+	}
+
+	catalogChanged := !sameCatalogFields(&before, book)
+	availabilityChanged := availability != nil &&
+		(existing.SupplierAvailability == nil || *existing.SupplierAvailability != *availability)
+	if !catalogChanged && !availabilityChanged {
+		return "skipped", nil
+	}
+	if catalogChanged {
+		setOriginalTranslation(book)
+		book.Version = existing.Version
+		if dryRun {
+			normalizeTranslations(book)
+			err = validateBookFields(book)
+		} else {
+			err = s.books.UpdateBook(ctx, book)
+		}
+		if err != nil {
+			return "failed", err
+		}
+	}
+	if availabilityChanged && !dryRun {
+		if err := s.books.SetSupplierAvailability(ctx, existing.ID, availability); err != nil {
+			return "failed", err
+		}
+	}
+	return "updated", nil
+}
+
+// overlay переносит в книгу то, что есть в записи; чего в записи нет, остаётся как было.
+// Остаток магазина фид не меняет: наличие у издателя хранится отдельно (supplierAvailability)
+func (s *onixService) overlay(book *models.Book, p *onix.Product) {
+	if p.Title != "" {
+		book.Title = p.Title
+	}
+	if p.Subtitle != "" {
+		book.Subtitle = p.Subtitle
+	}
+	if p.Description != "" {
+		book.Description = p.Description
+	}
+	if len(p.Contributors) > 0 {
+		book.Author = strings.Join(p.Contributors, ", ")
+	}
+	if price, ok := p.PriceIn(s.currency); ok {
+		book.Price = math.Round(price*100) / 100
+	}
+}
+
+// supplierAvailability остаток у издателя: Stock/OnHand, 0 — если издатель сообщил, что товар недоступен;
+// nil — в записи об этом ничего нет, сохранённое значение остаётся
+func supplierAvailability(p *onix.Product) *int {
+	switch {
+	case p.OnHand != nil:
+		n := max(*p.OnHand, 0)
+		return &n
+	case p.Unavailable():
+		n := 0
+		return &n
+	default:
+		return nil
+	}
+}
+
+// sameCatalogFields поля, которые может изменить ONIX, совпадают
+func sameCatalogFields(a, b *models.Book) bool {
+	return a.Title == b.Title && a.Subtitle == b.Subtitle && a.Description == b.Description &&
+		a.Author == b.Author && a.Price == b.Price
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/onix"
+	"testing"
+)
+
+// TestOverlayKeepsQuantity фид меняет поля каталога, но не остаток магазина
+func TestOverlayKeepsQuantity(t *testing.T) {
+	s := NewOnixService(nil, metrics.Nop(), "USD").(*onixService)
+	onHand := 40
+	book := &models.Book{Title: "Old", Author: "A", Price: 5, Quantity: 3}
+	s.overlay(book, &onix.Product{
+		Title:        "Dune",
+		Contributors: []string{"Frank Herbert"},
+		Availability: "40",
+		OnHand:       &onHand,
+		Prices:       []onix.Price{{Type: "02", Amount: 12.499, Currency: "USD"}},
+	})
+	if book.Quantity != 3 {
+		t.Errorf("quantity = %d, want 3", book.Quantity)
+	}
+	if book.Title != "Dune" || book.Author != "Frank Herbert" || book.Price != 12.5 {
+		t.Errorf("book = %+v", book)
+	}
+}
+
+// TestSupplierAvailability Stock/OnHand важнее кода наличия; без обоих значение не меняется
+func TestSupplierAvailability(t *testing.T) {
+	onHand, negative := 7, -2
+	for _, tc := range []struct {
+		name string
+		p    onix.Product
+		want *int
+	}{
+		{"on hand", onix.Product{Availability: "21", OnHand: &onHand}, &onHand},
+		{"on hand wins", onix.Product{Availability: "40", OnHand: &onHand}, &onHand},
+		{"negative on hand", onix.Product{OnHand: &negative}, new(int)},
+		{"unavailable", onix.Product{Availability: "51"}, new(int)},
+		{"unknown", onix.Product{Availability: "21"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := supplierAvailability(&tc.p)
+			if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+				t.Errorf("supplierAvailability = %v, want %v", deref(got), deref(tc.want))
+			}
+		})
+	}
+}
+
+func deref(n *int) any {
+	if n == nil {
+		return nil
+	}
+	return *n
+}
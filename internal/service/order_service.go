@@ -0,0 +1,57 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+// OrderService оформление заказов
+type OrderService interface {
+	Checkout(ctx context.Context, o *models.Order) error
+}
+
+type orderService struct {
+	repo repository.OrderRepository
+
+	ordersPlaced metrics.Counter
+}
+
+func NewOrderService(repo repository.OrderRepository, reg metrics.Registry) OrderService {
+	return &orderService{
+		repo:         repo,
+		ordersPlaced: reg.Counter("orders_placed_total", "Orders placed by location.", "location"),
+	}
+}
+
+// Checkout списывает товар с точки o.LocationID или выкупает резерв o.ReservationID;
+// в o возвращаются id, цены строк и сумма
+func (s *orderService) Checkout(ctx context.Context, o *models.Order) (err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.Checkout")
+	defer tracing.End(span, &err)
+
+	// Точку и строки заказа по резерву репозиторий берёт из самого резерва
+	switch {
+	case o.ReservationID < 0:
+		return wrong.ErrInvalidReservationID
+	case o.ReservationID == 0:
+		if err := validation.Struct(o); err != nil {
+			logging.FromContext(ctx).Warn("Error validating order", zap.Error(err))
+			return err
+		}
+	}
+	if err := s.repo.PlaceOrder(ctx, o); err != nil {
+		return err
+	}
+	s.ordersPlaced.Inc(strconv.Itoa(o.LocationID))
+	logging.FromContext(ctx).Info("Order placed", zap.Int("id", o.ID), zap.Int("locationID", o.LocationID),
+		zap.Int("lines", len(o.Items)), zap.Float64("total", o.Total))
+	return nil
+}
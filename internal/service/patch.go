@@ -0,0 +1,37 @@
+package service
+
+import (
+	"Bookstore/internal/mergepatch"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+// applyMergePatch накладывает RFC 7396 патч на текущее состояние ресурса и раскладывает
+// результат в dst. Возвращает json имена полей, которые прислал клиент
+func applyMergePatch(current any, patch []byte, dst any) ([]string, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, fields, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return nil, wrong.ErrMalformedBody.Wrap(err)
+	}
+
+	if err := json.Unmarshal(merged, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, validation.TypeMismatch(typeErr.Field, typeErr.Type.String()).Wrap(err)
+		}
+		return nil, wrong.ErrMalformedBody.Wrap(err)
+	}
+	return fields, nil
+}
+
+func patched(fields []string, name string) bool {
+	return slices.Contains(fields, name)
+}
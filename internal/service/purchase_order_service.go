@@ -0,0 +1,155 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"go.uber.org/zap"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// PurchaseOrderService заказы поставщикам: draft -> sent -> partially_received -> received
+type PurchaseOrderService interface {
+	CreatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error
+	GetPurchaseOrderByID(ctx context.Context, id int) (*models.PurchaseOrder, error)
+	GetPurchaseOrders(ctx context.Context, status string, supplierID int, before int64, limit int) ([]*models.PurchaseOrder, error)
+	UpdatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error
+	SendPurchaseOrder(ctx context.Context, id int) (*models.PurchaseOrder, error)
+	ReceivePurchaseOrder(ctx context.Context, id int, lines []models.ReceiptLine) (*models.PurchaseOrder, error)
+}
+
+type purchaseOrderService struct {
+	repo repository.PurchaseOrderRepository
+
+	transitions metrics.Counter
+}
+
+func NewPurchaseOrderService(repo repository.PurchaseOrderRepository, reg metrics.Registry) PurchaseOrderService {
+	return &purchaseOrderService{
+		repo:        repo,
+		transitions: reg.Counter("purchase_orders_total", "Purchase orders entering each status.", "status"),
+	}
+}
+
+var purchaseStatuses = []string{models.PurchaseDraft, models.PurchaseSent, models.PurchasePartiallyReceived, models.PurchaseReceived}
+
+func (s *purchaseOrderService) CreatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) (err error) {
+	ctx, span := tracing.Start(ctx, "PurchaseOrderService.CreatePurchaseOrder")
+	defer tracing.End(span, &err)
+
+	if err := validatePurchaseOrder(ctx, po); err != nil {
+		return err
+	}
+	if err := s.repo.CreatePurchaseOrder(ctx, po); err != nil {
+		return err
+	}
+	s.transitions.Inc(po.Status)
+	logging.FromContext(ctx).Info("Purchase order created", zap.Int("id", po.ID), zap.Int("supplierID", po.SupplierID),
+		zap.Int("lines", len(po.Lines)))
+	return nil
+}
+
+func (s *purchaseOrderService) GetPurchaseOrderByID(ctx context.Context, id int) (_ *models.PurchaseOrder, err error) {
+	ctx, span := tracing.Start(ctx, "PurchaseOrderService.GetPurchaseOrderByID")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidPurchaseOrderID
+	}
+	return s.repo.GetPurchaseOrderByID(ctx, id)
+}
+
+func (s *purchaseOrderService) GetPurchaseOrders(ctx context.Context, status string, supplierID int, before int64, limit int) (_ []*models.PurchaseOrder, err error) {
+	ctx, span := tracing.Start(ctx, "PurchaseOrderService.GetPurchaseOrders")
+	defer tracing.End(span, &err)
+
+	if status != "" && !slices.Contains(purchaseStatuses, status) {
+		return nil, wrong.Validation(wrong.FieldError{Field: "status", Code: "oneof", Param: strings.Join(purchaseStatuses, " "),
+			Message: "status must be one of " + strings.Join(purchaseStatuses, ", ")})
+	}
+	return s.repo.GetPurchaseOrders(ctx, status, supplierID, before, limit)
+}
+
+// UpdatePurchaseOrder заменяет черновик целиком; отправленный заказ менять нельзя
+func (s *purchaseOrderService) UpdatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) (err error) {
+	ctx, span := tracing.Start(ctx, "PurchaseOrderService.UpdatePurchaseOrder")
+	defer tracing.End(span, &err)
+
+	if po.ID <= 0 {
+		return wrong.ErrInvalidPurchaseOrderID
+	}
+	if err := validatePurchaseOrder(ctx, po); err != nil {
+		return err
+	}
+	return s.repo.UpdatePurchaseOrder(ctx, po)
+}
+
+func (s *purchaseOrderService) SendPurchaseOrder(ctx context.Context, id int) (_ *models.PurchaseOrder, err error) {
+	ctx, span := tracing.Start(ctx, "PurchaseOrderService.SendPurchaseOrder")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidPurchaseOrderID
+	}
+	po, err := s.repo.SendPurchaseOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.transitions.Inc(po.Status)
+	logging.FromContext(ctx).Info("Purchase order sent", zap.Int("id", id))
+	return po, nil
+}
+
+// ReceivePurchaseOrder строки с одним номером складываются; в ответе заказ после приёмки
+func (s *purchaseOrderService) ReceivePurchaseOrder(ctx context.Context, id int, lines []models.ReceiptLine) (_ *models.PurchaseOrder, err error) {
+	ctx, span := tracing.Start(ctx, "PurchaseOrderService.ReceivePurchaseOrder")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidPurchaseOrderID
+	}
+	receipt := struct {
+		Lines []models.ReceiptLine `json:"lines" validate:"required,min=1,max=200,dive"`
+	}{Lines: lines}
+	if err := validation.Struct(&receipt); err != nil {
+		logging.FromContext(ctx).Warn("Error validating receipt", zap.Error(err))
+		return nil, err
+	}
+
+	po, err := s.repo.ReceivePurchaseOrder(ctx, id, mergeReceiptLines(lines))
+	if err != nil {
+		return nil, err
+	}
+	s.transitions.Inc(po.Status)
+	logging.FromContext(ctx).Info("Purchase order received", zap.Int("id", id), zap.String("status", po.Status))
+	return po, nil
+}
+
+func validatePurchaseOrder(ctx context.Context, po *models.PurchaseOrder) error {
+	if err := validation.Struct(po); err != nil {
+		logging.FromContext(ctx).Warn("Error validating purchase order", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// mergeReceiptLines складывает приёмку по одной строке заказа и сортирует по номеру строки
+func mergeReceiptLines(lines []models.ReceiptLine) []models.ReceiptLine {
+	byLine := map[int]int{}
+	for _, l := range lines {
+		byLine[l.Line] += l.Quantity
+	}
+	merged := make([]models.ReceiptLine, 0, len(byLine))
+	for line, quantity := range byLine {
+		merged = append(merged, models.ReceiptLine{Line: line, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Line < merged[j].Line })
+	return merged
+}
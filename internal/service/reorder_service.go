@@ -0,0 +1,107 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/notify"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"strings"
+)
+
+// ReorderService точки заказа: отчёт с рекомендуемыми закупками и проверка для планировщика
+type ReorderService interface {
+	Report(ctx context.Context, days int) ([]*models.ReorderSuggestion, error)
+	CheckLowStock(ctx context.Context) (int, error)
+}
+
+type reorderService struct {
+	repo     repository.ReorderRepository
+	notifier notify.Notifier
+	days     int
+
+	alerts metrics.Counter
+}
+
+// NewReorderService days — окно, по которому считается скорость продаж в алертах
+func NewReorderService(repo repository.ReorderRepository, notifier notify.Notifier, reg metrics.Registry, days int) ReorderService {
+	return &reorderService{
+		repo:     repo,
+		notifier: notifier,
+		days:     days,
+		alerts:   reg.Counter("reorder_alerts_total", "Books reported as low on stock."),
+	}
+}
+
+// Report книги на точке заказа или ниже; скорость продаж считается за последние days дней
+func (s *reorderService) Report(ctx context.Context, days int) (_ []*models.ReorderSuggestion, err error) {
+	ctx, span := tracing.Start(ctx, "ReorderService.Report")
+	defer tracing.End(span, &err)
+
+	books, err := s.repo.LowStock(ctx, days, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range books {
+		suggest(b)
+	}
+	return books, nil
+}
+
+// CheckLowStock одно оповещение на все книги, впервые опустившиеся до точки заказа.
+// Если отправка не удалась, книги не отмечаются и попадут в следующую проверку
+func (s *reorderService) CheckLowStock(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "ReorderService.CheckLowStock")
+	defer tracing.End(span, &err)
+
+	if _, err := s.repo.ClearRecovered(ctx); err != nil {
+		return 0, err
+	}
+	books, err := s.repo.LowStock(ctx, s.days, true)
+	if err != nil || len(books) == 0 {
+		return 0, err
+	}
+	for _, b := range books {
+		suggest(b)
+	}
+
+	if err := s.notifier.Notify(ctx, lowStockAlert(books)); err != nil {
+		logging.FromContext(ctx).Error("Failed to send low stock alert", zap.Int("books", len(books)), zap.Error(err))
+		return 0, err
+	}
+	if err := s.repo.MarkAlerted(ctx, books); err != nil {
+		return 0, err
+	}
+	s.alerts.Add(float64(len(books)))
+	return len(books), nil
+}
+
+// suggest докупить столько, чтобы остаток поднялся выше точки заказа и покрыл продажи
+// ещё на столько же дней при текущей скорости, но не меньше минимальной партии
+func suggest(b *models.ReorderSuggestion) {
+	if b.Days > 0 {
+		b.DailySales = math.Round(float64(b.Sold)/float64(b.Days)*100) / 100
+	}
+	// Продажи за следующие Days дней ожидаются такими же, как за прошедшие
+	b.SuggestedQuantity = max(b.ReorderPoint+b.Sold-b.Quantity, b.ReorderPoint-b.Quantity+1, b.ReorderQuantity)
+}
+
+func lowStockAlert(books []*models.ReorderSuggestion) notify.Alert {
+	var text strings.Builder
+	text.WriteString("These books are at or below their reorder point:\n\n")
+	for _, b := range books {
+		fmt.Fprintf(&text, "#%d %s (%s): %d left, reorder point %d, sold %d in %d days, suggested order %d\n",
+			b.BookID, b.Title, b.Author, b.Quantity, b.ReorderPoint, b.Sold, b.Days, b.SuggestedQuantity)
+	}
+	return notify.Alert{
+		Kind:    "low_stock",
+		Subject: fmt.Sprintf("Low stock: %d book(s) need reordering", len(books)),
+		Text:    text.String(),
+		Data:    books,
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// ReservationService резервы корзины на время оплаты. Истёкшие резервы освобождает сборщик
+// (ExpireReservations), оплаченные превращает в заказ OrderService.Checkout
+type ReservationService interface {
+	Reserve(ctx context.Context, r *models.Reservation) error
+	GetReservation(ctx context.Context, id int) (*models.Reservation, error)
+	Release(ctx context.Context, id int) error
+	ExpireReservations(ctx context.Context) (int64, error)
+}
+
+type reservationService struct {
+	repo repository.ReservationRepository
+	ttl  time.Duration
+
+	reservations metrics.Counter
+}
+
+// NewReservationService ttl — сколько резерв держит товар
+func NewReservationService(repo repository.ReservationRepository, reg metrics.Registry, ttl time.Duration) ReservationService {
+	return &reservationService{
+		repo:         repo,
+		ttl:          ttl,
+		reservations: reg.Counter("reservations_total", "Stock reservations by outcome.", "outcome"),
+	}
+}
+
+// Reserve одинаковые книги в корзине складываются в одну строку
+func (s *reservationService) Reserve(ctx context.Context, r *models.Reservation) (err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.Reserve")
+	defer tracing.End(span, &err)
+
+	if err := validation.Struct(r); err != nil {
+		logging.FromContext(ctx).Warn("Error validating reservation", zap.Error(err))
+		return err
+	}
+	r.Items = mergeLines(r.Items)
+
+	if err := s.repo.CreateReservation(ctx, r, s.ttl); err != nil {
+		return err
+	}
+	s.reservations.Inc("created")
+	logging.FromContext(ctx).Info("Stock reserved", zap.Int("id", r.ID), zap.Int("locationID", r.LocationID),
+		zap.Time("expiresAt", r.ExpiresAt))
+	return nil
+}
+
+func (s *reservationService) GetReservation(ctx context.Context, id int) (_ *models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.GetReservation")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidReservationID
+	}
+	return s.repo.GetReservation(ctx, id)
+}
+
+func (s *reservationService) Release(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.Release")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return wrong.ErrInvalidReservationID
+	}
+	if err := s.repo.ReleaseReservation(ctx, id); err != nil {
+		return err
+	}
+	s.reservations.Inc("released")
+	logging.FromContext(ctx).Info("Reservation released", zap.Int("id", id))
+	return nil
+}
+
+// ExpireReservations вызывается сборщиком по расписанию
+func (s *reservationService) ExpireReservations(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.ExpireReservations")
+	defer tracing.End(span, &err)
+
+	n, err := s.repo.ExpireReservations(ctx)
+	if err != nil {
+		return 0, err
+	}
+	s.reservations.Add(float64(n), "expired")
+	return n, nil
+}
+
+// mergeLines складывает строки с одной книгой и сортирует по id книги
+func mergeLines(lines []models.ReservationLine) []models.ReservationLine {
+	byBook := map[int]int{}
+	for _, line := range lines {
+		byBook[line.BookID] += line.Quantity
+	}
+	merged := make([]models.ReservationLine, 0, len(byBook))
+	for bookID, quantity := range byBook {
+		merged = append(merged, models.ReservationLine{BookID: bookID, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].BookID < merged[j].BookID })
+	return merged
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/metrics"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"go.uber.org/zap"
+)
+
+// StockService движение товара: каждое изменение остатка — запись в журнале
+type StockService interface {
+	Adjust(ctx context.Context, m *models.StockMovement) error
+	History(ctx context.Context, bookID int, before int64, limit int) ([]*models.StockMovement, error)
+}
+
+type stockService struct {
+	repo repository.StockRepository
+
+	movements metrics.Counter
+}
+
+func NewStockService(repo repository.StockRepository, reg metrics.Registry) StockService {
+	return &stockService{
+		repo:      repo,
+		movements: reg.Counter("stock_movements_total", "Stock movements by kind.", "kind"),
+	}
+}
+
+// Adjust записывает движение и меняет остаток; в m возвращаются id, остаток после движения и время
+func (s *stockService) Adjust(ctx context.Context, m *models.StockMovement) (err error) {
+	ctx, span := tracing.Start(ctx, "StockService.Adjust")
+	defer tracing.End(span, &err)
+
+	if m.BookID <= 0 {
+		return wrong.ErrBookIDZero
+	}
+	if err := validation.Struct(m); err != nil {
+		logging.FromContext(ctx).Warn("Error validating stock movement", zap.Error(err))
+		return err
+	}
+	if !m.ValidDelta() {
+		return wrong.ErrStockDeltaSign
+	}
+
+	if err := s.repo.Record(ctx, m); err != nil {
+		return err
+	}
+	s.movements.Inc(m.Kind)
+	logging.FromContext(ctx).Info("Stock movement recorded", zap.Int("bookID", m.BookID), zap.Int("locationID", m.LocationID), zap.String("kind", m.Kind),
+		zap.Int("delta", m.Delta), zap.Int("balance", m.Balance))
+	return nil
+}
+
+func (s *stockService) History(ctx context.Context, bookID int, before int64, limit int) (_ []*models.StockMovement, err error) {
+	ctx, span := tracing.Start(ctx, "StockService.History")
+	defer tracing.End(span, &err)
+
+	if bookID <= 0 {
+		return nil, wrong.ErrBookIDZero
+	}
+	return s.repo.History(ctx, bookID, before, limit)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// SupplierService справочник поставщиков
+type SupplierService interface {
+	CreateSupplier(ctx context.Context, s *models.Supplier) error
+	GetAllSuppliers(ctx context.Context) ([]*models.Supplier, error)
+	GetSupplierByID(ctx context.Context, id int) (*models.Supplier, error)
+	UpdateSupplier(ctx context.Context, s *models.Supplier) error
+}
+
+type supplierService struct {
+	repo repository.SupplierRepository
+}
+
+func NewSupplierService(repo repository.SupplierRepository) SupplierService {
+	return &supplierService{repo: repo}
+}
+
+func (s *supplierService) CreateSupplier(ctx context.Context, supplier *models.Supplier) (err error) {
+	ctx, span := tracing.Start(ctx, "SupplierService.CreateSupplier")
+	defer tracing.End(span, &err)
+
+	if err := validateSupplier(ctx, supplier); err != nil {
+		return err
+	}
+	return s.repo.CreateSupplier(ctx, supplier)
+}
+
+func (s *supplierService) GetAllSuppliers(ctx context.Context) (_ []*models.Supplier, err error) {
+	ctx, span := tracing.Start(ctx, "SupplierService.GetAllSuppliers")
+	defer tracing.End(span, &err)
+
+	return s.repo.GetAllSuppliers(ctx)
+}
+
+func (s *supplierService) GetSupplierByID(ctx context.Context, id int) (_ *models.Supplier, err error) {
+	ctx, span := tracing.Start(ctx, "SupplierService.GetSupplierByID")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidSupplierID
+	}
+	return s.repo.GetSupplierByID(ctx, id)
+}
+
+// UpdateSupplier заменяет все поля; срок поставки уже отправленных заказов не пересчитывается
+func (s *supplierService) UpdateSupplier(ctx context.Context, supplier *models.Supplier) (err error) {
+	ctx, span := tracing.Start(ctx, "SupplierService.UpdateSupplier")
+	defer tracing.End(span, &err)
+
+	if supplier.ID <= 0 {
+		return wrong.ErrInvalidSupplierID
+	}
+	if err := validateSupplier(ctx, supplier); err != nil {
+		return err
+	}
+	return s.repo.UpdateSupplier(ctx, supplier)
+}
+
+func validateSupplier(ctx context.Context, supplier *models.Supplier) error {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	supplier.Email = strings.TrimSpace(supplier.Email)
+	if err := validation.Struct(supplier); err != nil {
+		logging.FromContext(ctx).Warn("Error validating supplier", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"Bookstore/internal/logging"
+	"Bookstore/internal/models"
+	"Bookstore/internal/repository"
+	"Bookstore/internal/tracing"
+	"Bookstore/internal/validation"
+	"Bookstore/internal/wrong"
+	"context"
+	"go.uber.org/zap"
+)
+
+// TransferService перемещения товара между точками: отправка списывает, приёмка приходует
+type TransferService interface {
+	CreateTransfer(ctx context.Context, t *models.Transfer) error
+	ReceiveTransfer(ctx context.Context, id int) (*models.Transfer, error)
+	GetTransferByID(ctx context.Context, id int) (*models.Transfer, error)
+	GetTransfers(ctx context.Context, status string, before int64, limit int) ([]*models.Transfer, error)
+}
+
+type transferService struct {
+	repo repository.TransferRepository
+}
+
+func NewTransferService(repo repository.TransferRepository) TransferService {
+	return &transferService{repo: repo}
+}
+
+func (s *transferService) CreateTransfer(ctx context.Context, t *models.Transfer) (err error) {
+	ctx, span := tracing.Start(ctx, "TransferService.CreateTransfer")
+	defer tracing.End(span, &err)
+
+	if err := validation.Struct(t); err != nil {
+		logging.FromContext(ctx).Warn("Error validating transfer", zap.Error(err))
+		return err
+	}
+	if t.FromLocationID == t.ToLocationID {
+		return wrong.ErrSameLocation
+	}
+
+	if err := s.repo.CreateTransfer(ctx, t); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Transfer created", zap.Int("id", t.ID), zap.Int("bookID", t.BookID),
+		zap.Int("from", t.FromLocationID), zap.Int("to", t.ToLocationID), zap.Int("quantity", t.Quantity))
+	return nil
+}
+
+func (s *transferService) ReceiveTransfer(ctx context.Context, id int) (_ *models.Transfer, err error) {
+	ctx, span := tracing.Start(ctx, "TransferService.ReceiveTransfer")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidTransferID
+	}
+	t, err := s.repo.ReceiveTransfer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("Transfer received", zap.Int("id", t.ID))
+	return t, nil
+}
+
+func (s *transferService) GetTransferByID(ctx context.Context, id int) (_ *models.Transfer, err error) {
+	ctx, span := tracing.Start(ctx, "TransferService.GetTransferByID")
+	defer tracing.End(span, &err)
+
+	if id <= 0 {
+		return nil, wrong.ErrInvalidTransferID
+	}
+	return s.repo.GetTransferByID(ctx, id)
+}
+
+func (s *transferService) GetTransfers(ctx context.Context, status string, before int64, limit int) (_ []*models.Transfer, err error) {
+	ctx, span := tracing.Start(ctx, "TransferService.GetTransfers")
+	defer tracing.End(span, &err)
+
+	if status != "" && status != models.TransferInTransit && status != models.TransferReceived {
+		return nil, wrong.Validation(wrong.FieldError{Field: "status", Code: "oneof", Param: models.TransferInTransit + " " + models.TransferReceived,
+			Message: "status must be one of in_transit, received"})
+	}
+	return s.repo.GetTransfers(ctx, status, before, limit)
+}
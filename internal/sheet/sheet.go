@@ -0,0 +1,321 @@
+// Package sheet читает и пишет табличные файлы (CSV и XLSX) построчно, без сторонних библиотек.
+// XLSX читается только первый лист: значения ячеек как строки, формулы — последним вычисленным значением
+package sheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Форматы файлов
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// Reader отдаёт строки таблицы по одной; после последней — io.EOF
+type Reader interface {
+	Read() ([]string, error)
+}
+
+// Open читает data как файл формата format
+func Open(format string, data []byte) (Reader, error) {
+	switch format {
+	case CSV:
+		return NewCSVReader(bytes.NewReader(data)), nil
+	case XLSX:
+		return NewXLSXReader(bytes.NewReader(data), int64(len(data)))
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// FormatOf формат по расширению имени файла; пустая строка — неизвестный
+func FormatOf(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv", ".txt":
+		return CSV
+	case ".xlsx":
+		return XLSX
+	default:
+		return ""
+	}
+}
+
+// NewCSVReader разделитель (',', ';' или табуляция) определяется по первой строке; BOM пропускается.
+// ' перед формулой, который дописывает Writer, снимается, поэтому выгрузка загружается обратно без изменений
+func NewCSVReader(r io.Reader) Reader {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		_, _ = br.Discard(3)
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = sniffDelimiter(br)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	return csvReader{r: cr}
+}
+
+type csvReader struct {
+	r *csv.Reader
+}
+
+func (c csvReader) Read() ([]string, error) {
+	record, err := c.r.Read()
+	for i, v := range record {
+		if len(v) > 1 && v[0] == '\'' && strings.IndexByte(formulaPrefixes, v[1]) >= 0 {
+			record[i] = v[1:]
+		}
+	}
+	return record, err
+}
+
+// sniffDelimiter разделитель, которого в первой строке больше всего
+func sniffDelimiter(br *bufio.Reader) rune {
+	head, _ := br.Peek(br.Size())
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i]
+	}
+	best, count := ',', bytes.Count(head, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(head, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// xlsxReader читает лист потоково; пропущенные в файле строки отдаются пустыми,
+// чтобы номер строки у читающего совпадал с номером строки в Excel
+type xlsxReader struct {
+	dec     *xml.Decoder
+	closer  io.Closer
+	strings []string
+	next    int // номер строки, которую вернёт следующий Read
+	pending []string
+	pendRow int
+}
+
+// NewXLSXReader открывает книгу и готовит к чтению её первый лист
+func NewXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx file: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := sharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("xlsx: sheet %s is missing", sheetPath)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxReader{dec: xml.NewDecoder(rc), closer: rc, strings: shared, next: 1}, nil
+}
+
+// firstSheet путь к первому листу книги по workbook.xml и его связям
+func firstSheet(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("xlsx: workbook has no sheets")
+	}
+	if err := decodeFile(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "xl/worksheets/sheet1.xml", nil
+}
+
+// sharedStrings таблица общих строк; в книге без текстовых ячеек её может не быть
+func sharedStrings(files map[string]*zip.File) ([]string, error) {
+	if _, ok := files["xl/sharedStrings.xml"]; !ok {
+		return nil, nil
+	}
+	var sst struct {
+		Items []struct {
+			T    string `xml:"t"`
+			Runs []struct {
+				T string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodeFile(files, "xl/sharedStrings.xml", &sst); err != nil {
+		return nil, err
+	}
+	out := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		text := si.T
+		for _, r := range si.Runs {
+			text += r.T
+		}
+		out[i] = text
+	}
+	return out, nil
+}
+
+func decodeFile(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("xlsx: %s is missing", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close()
+	}(rc)
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("xlsx: %s: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxCell ячейка листа: r — адрес (B7), t — тип значения
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if x.pending == nil {
+		row, record, err := x.readRow()
+		if err != nil {
+			_ = x.closer.Close()
+			return nil, err
+		}
+		x.pending, x.pendRow = record, row
+	}
+	if x.pendRow > x.next {
+		x.next++
+		return []string{}, nil
+	}
+	record := x.pending
+	x.pending = nil
+	x.next = x.pendRow + 1
+	return record, nil
+}
+
+// readRow следующий элемент <row> с его номером
+func (x *xlsxReader) readRow() (int, []string, error) {
+	for {
+		tok, err := x.dec.Token()
+		if err != nil {
+			return 0, nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row struct {
+			R     int        `xml:"r,attr"`
+			Cells []xlsxCell `xml:"c"`
+		}
+		if err := x.dec.DecodeElement(&row, &start); err != nil {
+			return 0, nil, err
+		}
+		if row.R == 0 {
+			row.R = x.next
+		}
+
+		var record []string
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			record[col] = x.value(c)
+		}
+		if record == nil {
+			record = []string{}
+		}
+		return row.R, record, nil
+	}
+}
+
+func (x *xlsxReader) value(c xlsxCell) string {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(x.strings) {
+			return ""
+		}
+		return x.strings[i]
+	case "inlineStr":
+		text := c.Inline.T
+		for _, r := range c.Inline.Runs {
+			text += r.T
+		}
+		return text
+	default:
+		return c.Value
+	}
+}
+
+// columnIndex номер колонки с нуля по адресу ячейки: A1 -> 0, AB12 -> 27
+func columnIndex(ref string) int {
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		n = n*26 + int(ch-'A'+1)
+	}
+	return n - 1
+}
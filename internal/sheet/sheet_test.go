@@ -0,0 +1,67 @@
+package sheet
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readAll все строки таблицы до io.EOF
+func readAll(t *testing.T, r Reader) [][]string {
+	t.Helper()
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, record)
+	}
+}
+
+// TestCSVDelimiter разделитель определяется по первой строке, BOM не попадает в первую колонку
+func TestCSVDelimiter(t *testing.T) {
+	want := [][]string{{"isbn", "title", "price"}, {"9780306406157", "Dune, part 1", "9.99"}}
+	for _, tc := range []struct{ name, data string }{
+		{"comma", "isbn,title,price\n9780306406157,\"Dune, part 1\",9.99\n"},
+		{"semicolon", "isbn;title;price\n9780306406157;Dune, part 1;9.99\n"},
+		{"tab", "isbn\ttitle\tprice\n9780306406157\tDune, part 1\t9.99\n"},
+		{"bom", "\xef\xbb\xbfisbn;title;price\r\n9780306406157;Dune, part 1;9.99\r\n"},
+		{"no trailing newline", "isbn;title;price\n9780306406157;Dune, part 1;9.99"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := readAll(t, NewCSVReader(strings.NewReader(tc.data))); !reflect.DeepEqual(got, want) {
+				t.Errorf("rows = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// TestCSVRaggedRows строки разной длины не ошибка: недостающие колонки разбирает импорт
+func TestCSVRaggedRows(t *testing.T) {
+	got := readAll(t, NewCSVReader(strings.NewReader("isbn;title;price\n9780306406157;Dune\n")))
+	want := [][]string{{"isbn", "title", "price"}, {"9780306406157", "Dune"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+// TestFormatOf формат по расширению без учёта регистра
+func TestFormatOf(t *testing.T) {
+	for name, want := range map[string]string{
+		"books.csv":      CSV,
+		"books.TXT":      CSV,
+		"dir/Books.XLSX": XLSX,
+		"books.xls":      "",
+		"books":          "",
+	} {
+		if got := FormatOf(name); got != want {
+			t.Errorf("FormatOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Writer пишет таблицу построчно. Значения — string, int, float64 или nil (пустая ячейка);
+// в XLSX числа остаются числами. Close дописывает конец файла: без него XLSX не откроется
+type Writer interface {
+	Write(record []any) error
+	Close() error
+}
+
+// NewWriter пишет в w файл формата format по мере вызова Write, целиком в памяти он не держится
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case XLSX:
+		return NewXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(record []any) error {
+	values := make([]string, len(record))
+	for i, v := range record {
+		if s, ok := v.(string); ok {
+			values[i] = escapeFormula(s)
+			continue
+		}
+		values[i] = formatValue(v)
+	}
+	return c.w.Write(values)
+}
+
+// formulaPrefixes с этих символов табличные редакторы начинают формулу
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula текст, который Excel или LibreOffice приняли бы за формулу, получает ' в начале
+// и открывается как текст (CSV injection). Числа не экранируются; NewCSVReader этот ' снимает
+func escapeFormula(s string) string {
+	if s != "" && strings.IndexByte(formulaPrefixes, s[0]) >= 0 {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Служебные части книги XLSX: один лист, без стилей и общих строк — текст пишется inline,
+// поэтому лист можно писать потоково, не зная заранее всех значений
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+// NewXLSXWriter пишет служебные части книги и открывает лист; строки дописываются в него по одной
+func NewXLSXWriter(w io.Writer) (Writer, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) Write(record []any) error {
+	x.row++
+	row := strconv.Itoa(x.row)
+	b := x.sheet
+	_, _ = b.WriteString(`<row r="` + row + `">`)
+	for i, v := range record {
+		ref := columnName(i) + row
+		switch v := v.(type) {
+		case nil:
+			continue
+		case int:
+			_, _ = b.WriteString(`<c r="` + ref + `"><v>` + strconv.Itoa(v) + `</v></c>`)
+		case float64:
+			_, _ = b.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(v, 'f', -1, 64) + `</v></c>`)
+		default:
+			text := formatValue(v)
+			if text == "" {
+				continue
+			}
+			_, _ = b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(b, []byte(text)); err != nil {
+				return err
+			}
+			_, _ = b.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := b.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// columnName буквы колонки по номеру с нуля: 0 -> A, 27 -> AB (обратная к columnIndex)
+func columnName(i int) string {
+	var name strings.Builder
+	for n := i + 1; n > 0; n = (n - 1) / 26 {
+		name.WriteByte(byte('A' + (n-1)%26))
+	}
+	s := []byte(name.String())
+	for l, r := 0, len(s)-1; l < r; l, r = l+1, r-1 {
+		s[l], s[r] = s[r], s[l]
+	}
+	return string(s)
+}
@@ -0,0 +1,124 @@
+package sheet
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestXLSXRoundTrip что пишет NewXLSXWriter, читает NewXLSXReader: числа, текст со спецсимволами,
+// пустые ячейки, пустая строка и колонки дальше Z
+func TestXLSXRoundTrip(t *testing.T) {
+	wide := make([]any, 28)
+	wideWant := make([]string, 28)
+	for i := range wide {
+		wide[i] = columnName(i)
+		wideWant[i] = columnName(i)
+	}
+	records := [][]any{
+		{"id", "title", "price", "note"},
+		{1, "Dune & <Sons>", 9.99, nil},
+		{2, "  spaced  ", nil, "Ёлка"},
+		{},
+		wide,
+	}
+	want := [][]string{
+		{"id", "title", "price", "note"},
+		{"1", "Dune & <Sons>", "9.99"},
+		{"2", "  spaced  ", "", "Ёлка"},
+		{},
+		wideWant,
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(XLSX, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(XLSX, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, r); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+// TestCSVRoundTrip значения форматируются так, как их потом разберёт импорт
+func TestCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(CSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range [][]any{{"id", "title", "price", "translation_of"}, {7, "Dune, \"part\" 1", 12.5, nil}} {
+		if err := w.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readAll(t, NewCSVReader(strings.NewReader(buf.String())))
+	want := [][]string{{"id", "title", "price", "translation_of"}, {"7", "Dune, \"part\" 1", "12.5", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+// TestColumnName columnName и columnIndex обратны друг другу
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+		if got := columnIndex(want + "12"); got != i {
+			t.Errorf("columnIndex(%q) = %d, want %d", want+"12", got, i)
+		}
+	}
+}
+
+// TestUnsupportedFormat неизвестный формат — ошибка, а не пустой файл
+func TestUnsupportedFormat(t *testing.T) {
+	if _, err := NewWriter("ods", &bytes.Buffer{}); err == nil {
+		t.Error("NewWriter(ods) succeeded")
+	}
+}
+
+// TestCSVFormulaEscaping текст, похожий на формулу, пишется с ' и читается обратно без него; числа не трогаются
+func TestCSVFormulaEscaping(t *testing.T) {
+	record := []any{"=HYPERLINK(\"http://x\")", "+1", "-5% off", "@SUM(A1)", "\tcmd", "\rcmd", "Dune", -3, -2.5, "'quoted"}
+	var buf bytes.Buffer
+	w, err := NewWriter(CSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantRaw := "\"'=HYPERLINK(\"\"http://x\"\")\",'+1,'-5% off,'@SUM(A1),'\tcmd,\"'\rcmd\",Dune,-3,-2.5,'quoted\n"
+	if got := buf.String(); got != wantRaw {
+		t.Errorf("csv = %q, want %q", got, wantRaw)
+	}
+
+	got := readAll(t, NewCSVReader(strings.NewReader(buf.String())))
+	want := [][]string{{"=HYPERLINK(\"http://x\")", "+1", "-5% off", "@SUM(A1)", "\tcmd", "\rcmd", "Dune", "-3", "-2.5", "'quoted"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartSQL открывает client span для SQL запроса; имя — "SQL " + первое слово запроса
+func StartSQL(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := "QUERY"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	return tracer().Start(ctx, "SQL "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(query),
+			attribute.String("db.operation.name", operation),
+		),
+	)
+}
+
+// EndSQL закрывает SQL span; sql.ErrNoRows не считается ошибкой
+func EndSQL(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
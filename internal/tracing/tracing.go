@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName имя трейсера для всех span приложения
+const instrumentationName = "Bookstore"
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterMemory = "memory"
+)
+
+// Config выбор экспортера; адрес OTLP берётся из стандартных OTEL_EXPORTER_OTLP_* переменных
+type Config struct {
+	Exporter    string
+	ServiceName string
+}
+
+// LoadConfig читает OTEL_TRACES_EXPORTER и OTEL_SERVICE_NAME
+func LoadConfig() Config {
+	cfg := Config{
+		Exporter:    os.Getenv("OTEL_TRACES_EXPORTER"),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = ExporterNone
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "bookstore"
+	}
+	return cfg
+}
+
+// Provider глобальный TracerProvider; Memory заполнен только для экспортера memory (тесты)
+type Provider struct {
+	tp     *sdktrace.TracerProvider
+	Memory *tracetest.InMemoryExporter
+}
+
+// Setup настраивает глобальные TracerProvider и W3C propagator (traceparent, baggage)
+func Setup(ctx context.Context, cfg Config) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	p := &Provider{}
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone:
+		return p, nil
+	case ExporterOTLP:
+		otlp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+		}
+		exporter = otlp
+	case ExporterStdout:
+		stdout, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %v", err)
+		}
+		exporter = stdout
+	case ExporterMemory:
+		p.Memory = tracetest.NewInMemoryExporter()
+		exporter = p.Memory
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if cfg.Exporter == ExporterMemory {
+		// Синхронная отправка, чтобы тест видел span сразу после запроса
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	} else {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	p.tp = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(p.tp)
+	return p, nil
+}
+
+// Shutdown отправляет накопленные span; для экспортера none ничего не делает
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.tp == nil {
+		return nil
+	}
+	return p.tp.Shutdown(ctx)
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start открывает дочерний span; закрывать через End
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, opts...)
+}
+
+// End закрывает span и помечает его ошибкой; err — указатель на именованный результат
+//
+//	func (s *svc) Do(ctx context.Context) (err error) {
+//		ctx, span := tracing.Start(ctx, "svc.Do")
+//		defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
@@ -0,0 +1,160 @@
+package validation
+
+import (
+	"Bookstore/internal/models"
+	"Bookstore/internal/wrong"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Правила описываются тегом `validate:"..."` на структуре; кроме встроенных правил
+// validator/v10 доступны:
+//
+//	username — латиница, цифры, '.', '_' и '-'
+//	role     — одна из ролей models.IsValidRole
+//	price    — положительная цена не более чем с двумя знаками после запятой
+//	language — код языка ISO 639 в нижнем регистре: "en", "ru", "tk"
+//	isbn     — ISBN-13 без дефисов с верной контрольной цифрой (см. models.NormalizeISBN)
+var (
+	once     sync.Once
+	validate *validator.Validate
+
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	languagePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+)
+
+func engine() *validator.Validate {
+	once.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+
+		// В ошибках используем имя поля из json тега, как его видит клиент
+		validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return f.Name
+			}
+			return name
+		})
+
+		_ = validate.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+			return usernamePattern.MatchString(fl.Field().String())
+		})
+		_ = validate.RegisterValidation("role", func(fl validator.FieldLevel) bool {
+			return models.IsValidRole(fl.Field().String())
+		})
+		_ = validate.RegisterValidation("language", func(fl validator.FieldLevel) bool {
+			return languagePattern.MatchString(fl.Field().String())
+		})
+		_ = validate.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+			return models.ValidISBN13(fl.Field().String())
+		})
+		_ = validate.RegisterValidation("price", func(fl validator.FieldLevel) bool {
+			price := fl.Field().Float()
+			cents := price * 100
+			return price > 0 && math.Abs(cents-math.Round(cents)) < 1e-6
+		})
+	})
+	return validate
+}
+
+// Struct проверяет все поля сразу и возвращает одну ошибку wrong.Validation со всеми нарушениями
+func Struct(v any) error {
+	err := engine().Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+	return wrong.Validation(Fields(validationErrs)...)
+}
+
+// Fields переводит ошибки validator в ошибки полей с кодом правила и параметром
+func Fields(errs validator.ValidationErrors) []wrong.FieldError {
+	fields := make([]wrong.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, wrong.FieldError{
+			Field:   fe.Field(),
+			Code:    rule(fe),
+			Param:   fe.Param(),
+			Message: message(fe),
+		})
+	}
+	return fields
+}
+
+// Rules правила, для которых Fields выдаёт собственный код и сообщение;
+// по этому списку каталог i18n проверяет, что у каждого правила есть перевод
+func Rules() []string {
+	return []string{"required", "min", "max", "min_length", "max_length", "gt", "gte", "lte", "oneof", "username", "role", "price", "language", "isbn", "type", "invalid"}
+}
+
+// TypeMismatch ошибка поля, в котором пришло значение не того JSON типа
+func TypeMismatch(field, typ string) *wrong.Error {
+	return wrong.Validation(wrong.FieldError{Field: field, Code: "type", Param: typ, Message: field + " must be " + typ})
+}
+
+// rule код правила для клиента: у строк min/max означают длину, поэтому отдаём min_length/max_length
+func rule(fe validator.FieldError) string {
+	tag := fe.Tag()
+	if fe.Kind() == reflect.String && (tag == "min" || tag == "max") {
+		return tag + "_length"
+	}
+	switch tag {
+	case "required", "min", "max", "gt", "gte", "lte", "oneof", "username", "role", "price", "language", "isbn":
+		return tag
+	default:
+		// Для правил без перевода отдаём общий код invalid
+		return "invalid"
+	}
+}
+
+// message сообщение по умолчанию (английский), клиенту отдаётся вместе с кодом правила
+func message(fe validator.FieldError) string {
+	field, param := fe.Field(), fe.Param()
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min":
+		if isString {
+			return fmt.Sprintf("%s must be at least %s characters long", field, param)
+		}
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max":
+		if isString {
+			return fmt.Sprintf("%s must be at most %s characters long", field, param)
+		}
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s", field, param)
+	case "lte":
+		return fmt.Sprintf("%s must be less than or equal to %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(param, " ", ", "))
+	case "username":
+		return fmt.Sprintf("%s may contain only letters, digits, '.', '_' and '-'", field)
+	case "role":
+		return fmt.Sprintf("%s must be one of: %s, %s", field, models.RoleUser, models.RoleAdmin)
+	case "price":
+		return fmt.Sprintf("%s must be positive with at most two decimal places", field)
+	case "language":
+		return fmt.Sprintf("%s must be a lowercase ISO 639 language code", field)
+	case "isbn":
+		return fmt.Sprintf("%s must be a valid ISBN-10 or ISBN-13", field)
+	default:
+		return fmt.Sprintf("%s failed the %s rule", field, fe.Tag())
+	}
+}
@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"Bookstore/internal/wrong"
+	"errors"
+	"testing"
+)
+
+type sample struct {
+	Username string  `json:"username" validate:"required,username"`
+	Role     string  `json:"role,omitempty" validate:"omitempty,role"`
+	Price    float64 `json:"price" validate:"price"`
+}
+
+// TestCustomRules правила username, role и price
+func TestCustomRules(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   sample
+		want []wrong.FieldError
+	}{
+		{name: "valid", in: sample{Username: "john.doe_1-a", Role: "admin", Price: 12.5}},
+		{name: "cents", in: sample{Username: "a", Price: 0.01}},
+		{name: "bad username", in: sample{Username: "john doe", Price: 1},
+			want: []wrong.FieldError{{Field: "username", Code: "username"}}},
+		{name: "bad role", in: sample{Username: "a", Role: "root", Price: 1},
+			want: []wrong.FieldError{{Field: "role", Code: "role"}}},
+		{name: "zero price", in: sample{Username: "a"},
+			want: []wrong.FieldError{{Field: "price", Code: "price"}}},
+		{name: "negative price", in: sample{Username: "a", Price: -1},
+			want: []wrong.FieldError{{Field: "price", Code: "price"}}},
+		{name: "fractional cents", in: sample{Username: "a", Price: 9.999},
+			want: []wrong.FieldError{{Field: "price", Code: "price"}}},
+		{name: "all fields at once", in: sample{Role: "root", Price: 0.001},
+			want: []wrong.FieldError{{Field: "username", Code: "required"}, {Field: "role", Code: "role"}, {Field: "price", Code: "price"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Struct(tc.in)
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var e *wrong.Error
+			if !errors.As(err, &e) || e.Code != wrong.CodeValidation {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+			if len(e.Fields) != len(tc.want) {
+				t.Fatalf("fields = %+v, want %+v", e.Fields, tc.want)
+			}
+			for i, want := range tc.want {
+				got := e.Fields[i]
+				if got.Field != want.Field || got.Code != want.Code {
+					t.Errorf("field %d = %s/%s, want %s/%s", i, got.Field, got.Code, want.Field, want.Code)
+				}
+				if got.Message == "" {
+					t.Errorf("field %s has no message", got.Field)
+				}
+			}
+		})
+	}
+}
+
+// TestParamInMessage параметр правила попадает в ошибку поля и в сообщение
+func TestParamInMessage(t *testing.T) {
+	err := Struct(struct {
+		Title string `json:"title" validate:"max=3"`
+	}{Title: "Dune!"})
+	var e *wrong.Error
+	if !errors.As(err, &e) || len(e.Fields) != 1 {
+		t.Fatalf("err = %v, want one field error", err)
+	}
+	if got := e.Fields[0]; got.Param != "3" || got.Message != "title must be at most 3 characters long" {
+		t.Errorf("field error = %+v", got)
+	}
+}
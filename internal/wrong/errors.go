@@ -1,26 +1,129 @@
 package wrong
 
 import (
-	"errors"
+	"net/http"
 	"os"
 )
 
+// Коды ошибок — стабильные машиночитаемые идентификаторы для клиентов
+const (
+	CodeBadRequest            Code = "bad_request"
+	CodeMalformedBody         Code = "malformed_body"
+	CodeEmptyBook             Code = "empty_book"
+	CodeValidation            Code = "validation_failed"
+	CodeUnauthorized          Code = "unauthorized"
+	CodeInvalidToken          Code = "invalid_token"
+	CodeInvalidCredentials    Code = "invalid_credentials"
+	CodeForbidden             Code = "forbidden"
+	CodeRouteNotFound         Code = "route_not_found"
+	CodeUserNotFound          Code = "user_not_found"
+	CodeBookNotFound          Code = "book_not_found"
+	CodeUsernameTaken         Code = "username_taken"
+	CodeBookExists            Code = "book_exists"
+	CodeBookInUse             Code = "book_in_use"
+	CodeUnsupportedMedia      Code = "unsupported_media_type"
+	CodeNotAcceptable         Code = "not_acceptable"
+	CodeVersionMismatch       Code = "version_mismatch"
+	CodeUserVersionMismatch   Code = "user_version_mismatch"
+	CodePreconditionRequired  Code = "precondition_required"
+	CodeInsufficientStock     Code = "insufficient_stock"
+	CodeLocationNotFound      Code = "location_not_found"
+	CodeLocationExists        Code = "location_exists"
+	CodeTransferNotFound      Code = "transfer_not_found"
+	CodeTransferReceived      Code = "transfer_already_received"
+	CodeReservationNotFound   Code = "reservation_not_found"
+	CodeReservationInactive   Code = "reservation_inactive"
+	CodeSupplierNotFound      Code = "supplier_not_found"
+	CodeSupplierExists        Code = "supplier_exists"
+	CodePurchaseOrderNotFound Code = "purchase_order_not_found"
+	CodePurchaseOrderStatus   Code = "purchase_order_status"
+	CodeISBNExists            Code = "isbn_exists"
+	CodeImportJobNotFound     Code = "import_job_not_found"
+	CodeImportFileInvalid     Code = "import_file_invalid"
+	CodeImportTooLarge        Code = "import_too_large"
+	CodeOnixInvalid           Code = "onix_invalid"
+	CodeTimeout               Code = "timeout"
+	CodeClientClosed          Code = "client_closed_request"
+	CodeInternal              Code = "internal_error"
+)
+
+// Статус, который nginx использует для запросов, закрытых клиентом
+const StatusClientClosedRequest = 499
+
 var (
-	ErrUserNotFound   = errors.New("user not found")
-	ErrEmptyUsername  = errors.New("username cannot be empty")
-	ErrEmptyPassword  = errors.New("password cannot be empty")
-	ErrEmptyRole      = errors.New("role cannot be empty")
-	ErrUserIDZero     = errors.New("user ID cannot be zero")
-	ErrBookNotFound   = errors.New("book not found")
-	ErrEmptyBook      = errors.New("book cannot be empty")
-	JwtKey            = os.Getenv("JWT_SECRET")
-	ErrInvalidRequest = "Invalid request"
-	ErrInvalidToken   = "Invalid or expired token"
-	ErrInternalServer = "Internal server error"
-	SuccessMessage    = "User registered successfully"
-	ErrEmptyTitle     = errors.New("title cannot be empty")
-	ErrEmptyAuthor    = errors.New("author cannot be empty")
-	ErrEmptyPrice     = errors.New("price cannot be empty")
-	ErrBookIDZero     = errors.New("book ID cannot be zero")
-	ErrEmptyQuantity  = errors.New("quantity cannot be empty")
+	ErrUserNotFound           = New(CodeUserNotFound, http.StatusNotFound, "user not found")
+	ErrEmptyUsername          = Field("username", "required", "username cannot be empty")
+	ErrEmptyPassword          = Field("password", "required", "password cannot be empty")
+	ErrEmptyRole              = Field("role", "required", "role cannot be empty")
+	ErrInvalidRole            = Field("role", "role", "role must be user or admin")
+	ErrUserIDZero             = Field("id", "positive", "user ID cannot be zero")
+	ErrInvalidUserID          = Field("id", "integer", "user ID must be a positive integer")
+	ErrUsernameTaken          = New(CodeUsernameTaken, http.StatusConflict, "username is already taken")
+	ErrBookNotFound           = New(CodeBookNotFound, http.StatusNotFound, "book not found")
+	ErrBookExists             = New(CodeBookExists, http.StatusConflict, "book with this ID already exists")
+	ErrBookInUse              = New(CodeBookInUse, http.StatusConflict, "the book is referenced by orders or purchase orders and cannot be deleted")
+	ErrInsufficientStock      = New(CodeInsufficientStock, http.StatusConflict, "not enough stock for this movement")
+	ErrStockDeltaSign         = Field("delta", "sign", "delta must be positive for receipt and return, negative for sale and damage, non-zero for adjustment")
+	ErrLocationNotFound       = New(CodeLocationNotFound, http.StatusNotFound, "location not found")
+	ErrLocationExists         = New(CodeLocationExists, http.StatusConflict, "location with this code already exists")
+	ErrInvalidLocationID      = Field("id", "integer", "location ID must be a positive integer")
+	ErrSameLocation           = Field("to_location_id", "different", "to_location_id must differ from from_location_id")
+	ErrTransferNotFound       = New(CodeTransferNotFound, http.StatusNotFound, "transfer not found")
+	ErrTransferReceived       = New(CodeTransferReceived, http.StatusConflict, "transfer has already been received")
+	ErrInvalidTransferID      = Field("id", "integer", "transfer ID must be a positive integer")
+	ErrReservationNotFound    = New(CodeReservationNotFound, http.StatusNotFound, "reservation not found")
+	ErrReservationInactive    = New(CodeReservationInactive, http.StatusConflict, "reservation has expired or was already released or paid")
+	ErrInvalidReservationID   = Field("id", "integer", "reservation ID must be a positive integer")
+	ErrSupplierNotFound       = New(CodeSupplierNotFound, http.StatusNotFound, "supplier not found")
+	ErrSupplierExists         = New(CodeSupplierExists, http.StatusConflict, "supplier with this name already exists")
+	ErrInvalidSupplierID      = Field("id", "integer", "supplier ID must be a positive integer")
+	ErrPurchaseOrderNotFound  = New(CodePurchaseOrderNotFound, http.StatusNotFound, "purchase order not found")
+	ErrPurchaseOrderStatus    = New(CodePurchaseOrderStatus, http.StatusConflict, "purchase order status does not allow this action")
+	ErrInvalidPurchaseOrderID = Field("id", "integer", "purchase order ID must be a positive integer")
+	ErrUnknownOrderLine       = Field("line", "exists", "line must reference a line of the purchase order")
+	ErrReceiptExceedsOrder    = Field("quantity", "outstanding", "quantity must not exceed what is still outstanding on the line")
+	ErrISBNExists             = New(CodeISBNExists, http.StatusConflict, "another book already has this ISBN")
+	ErrImportJobNotFound      = New(CodeImportJobNotFound, http.StatusNotFound, "import job not found")
+	ErrInvalidImportJobID     = Field("id", "integer", "import job ID must be a positive integer")
+	ErrImportFileInvalid      = New(CodeImportFileInvalid, http.StatusBadRequest, "the file could not be read as CSV or XLSX")
+	ErrImportTooLarge         = New(CodeImportTooLarge, http.StatusRequestEntityTooLarge, "the file is too large to import")
+	ErrImportFileRequired     = Field("file", "required", "file is required")
+	ErrImportNoHeader         = Field("file", "header", "file must start with a header row")
+	ErrImportColumn           = Field("mapping", "column", "mapping refers to a column that is not in the file")
+	ErrImportDuplicate        = Field("isbn", "duplicate", "the same book appears in an earlier row of the file")
+	ErrImportKeyMismatch      = Field("id", "matches", "id does not match the book found by isbn")
+	ErrOnixInvalid            = New(CodeOnixInvalid, http.StatusBadRequest, "the file could not be read as an ONIX 3.0 message")
+	ErrVersionMismatch        = New(CodeVersionMismatch, http.StatusPreconditionFailed, "the book was changed by someone else, reload it and try again")
+	ErrUserVersionMismatch    = New(CodeUserVersionMismatch, http.StatusPreconditionFailed, "the user was changed by someone else, reload it and try again")
+	ErrPreconditionRequired   = New(CodePreconditionRequired, http.StatusPreconditionRequired, "If-Match header is required: send the ETag from a previous GET")
+	ErrNoOriginalBook         = Field("translation_of", "exists", "translation_of must reference an existing book")
+	ErrEmptyBook              = New(CodeEmptyBook, http.StatusBadRequest, "book cannot be empty")
+	ErrInvalidBookID          = Field("id", "integer", "book ID must be a positive integer")
+	ErrEmptyTitle             = Field("title", "required", "title cannot be empty")
+	ErrEmptyAuthor            = Field("author", "required", "author cannot be empty")
+	ErrBookIDZero             = Field("id", "positive", "book ID cannot be zero")
+	ErrMalformedBody          = New(CodeMalformedBody, http.StatusBadRequest, "request body is not valid JSON")
+	ErrUnsupportedMedia       = New(CodeUnsupportedMedia, http.StatusUnsupportedMediaType, "unsupported content type")
+	ErrNotAcceptable          = New(CodeNotAcceptable, http.StatusNotAcceptable, "the response is available as application/json, application/xml or text/csv")
+	ErrUnauthorized           = New(CodeUnauthorized, http.StatusUnauthorized, "authorization header required")
+	ErrInvalidJWT             = New(CodeInvalidToken, http.StatusUnauthorized, "invalid or expired token")
+	ErrBadCredentials         = New(CodeInvalidCredentials, http.StatusUnauthorized, "invalid username or password")
+	ErrForbidden              = New(CodeForbidden, http.StatusForbidden, "you don't have access to this resource")
+	ErrRouteNotFound          = New(CodeRouteNotFound, http.StatusNotFound, "route not found")
+	ErrTimeout                = New(CodeTimeout, http.StatusGatewayTimeout, "the request took too long")
+	ErrClientClosed           = New(CodeClientClosed, StatusClientClosedRequest, "client closed the request")
+	ErrInternal               = New(CodeInternal, http.StatusInternalServerError, "internal server error")
+	JwtKey                    = os.Getenv("JWT_SECRET")
 )
+
+// Коды сообщений об успехе; тексты на всех языках лежат в каталоге i18n
+const (
+	MsgUserRegistered MessageCode = "user_registered"
+	MsgUserUpdated    MessageCode = "user_updated"
+	MsgUserDeleted    MessageCode = "user_deleted"
+)
+
+// Messages все коды сообщений об успехе, по ним проверяется полнота каталога
+func Messages() []MessageCode {
+	return []MessageCode{MsgUserRegistered, MsgUserUpdated, MsgUserDeleted}
+}
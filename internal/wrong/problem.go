@@ -0,0 +1,35 @@
+package wrong
+
+import "net/http"
+
+// ProblemContentType медиа тип RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// Problem тело ответа об ошибке по RFC 7807 с расширениями code, request_id и errors
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Problem строит тело ответа; instance — путь запроса
+func (e *Error) Problem(instance string) Problem {
+	title := http.StatusText(e.Status)
+	if e.Status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	return Problem{
+		Type:     "/problems/" + string(e.Code),
+		Title:    title,
+		Status:   e.Status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+}
@@ -0,0 +1,145 @@
+package wrong
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Code машиночитаемый код ошибки
+type Code string
+
+// MessageCode код сообщения об успехе, например "user_registered"
+type MessageCode string
+
+// Реестр кодов и правил, объявленных через New и Field; нужен каталогу i18n для проверки полноты
+var (
+	registryMu sync.Mutex
+	codes      = map[Code]struct{}{}
+	rules      = map[string]struct{}{}
+)
+
+// FieldError ошибка одного поля запроса; Code — имя нарушенного правила, Param — его параметр (max=255 -> "255")
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error типизированная ошибка: код, HTTP статус и безопасное публичное сообщение.
+// Err — внутренняя причина, клиенту не показывается, только пишется в лог
+type Error struct {
+	Code    Code
+	Status  int
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func New(code Code, status int, message string) *Error {
+	registryMu.Lock()
+	codes[code] = struct{}{}
+	registryMu.Unlock()
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+// Field ошибка валидации одного поля
+func Field(field, rule, message string) *Error {
+	registryMu.Lock()
+	rules[rule] = struct{}{}
+	registryMu.Unlock()
+	return Validation(FieldError{Field: field, Code: rule, Message: message})
+}
+
+// Codes все коды ошибок, созданные через New, в отсортированном виде
+func Codes() []Code {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	out := make([]Code, 0, len(codes)+1)
+	for c := range codes {
+		out = append(out, c)
+	}
+	out = append(out, CodeValidation)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Rules имена правил, использованных в ошибках полей через Field
+func Rules() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	out := make([]string, 0, len(rules))
+	for r := range rules {
+		out = append(out, r)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Validation ошибка валидации сразу нескольких полей
+func Validation(fields ...FieldError) *Error {
+	message := "request validation failed"
+	if len(fields) == 1 {
+		message = fields[0].Message
+	}
+	return &Error{Code: CodeValidation, Status: http.StatusBadRequest, Message: message, Fields: fields}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is сравнивает по коду (и полям для ошибок валидации), поэтому копии из Wrap
+// совпадают со своим sentinel: errors.Is(ErrUserNotFound.Wrap(err), ErrUserNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.key() == t.key()
+}
+
+func (e *Error) key() string {
+	var b strings.Builder
+	b.WriteString(string(e.Code))
+	for _, f := range e.Fields {
+		b.WriteString("|" + f.Field + ":" + f.Code)
+	}
+	return b.String()
+}
+
+// Wrap копия ошибки с внутренней причиной
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// From приводит любую ошибку к *Error: типизированные отдаются как есть,
+// таймауты базы — 504, отключение клиента — 499, остальное — 500 без деталей
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout.Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return ErrClientClosed.Wrap(err)
+	default:
+		return ErrInternal.Wrap(err)
+	}
+}
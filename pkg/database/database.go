@@ -5,16 +5,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 // Инициализация переменных окружения
+// Без .env (контейнер, CLI из другой папки) используем переменные окружения процесса
 func init() {
 	err := godotenv.Load()
 	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+		log.Printf("No .env file loaded, using process environment: %v", err)
 	}
 }
 
@@ -44,3 +46,22 @@ func ConnectDB() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// DBName имя базы из окружения, используется как метка метрик пула
+func DBName() string {
+	return os.Getenv("DB_NAME")
+}
+
+// StatementTimeout таймаут одного SQL запроса из DB_STATEMENT_TIMEOUT (например "5s"), по умолчанию 10s
+func StatementTimeout() time.Duration {
+	value := os.Getenv("DB_STATEMENT_TIMEOUT")
+	if value == "" {
+		return 10 * time.Second
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid DB_STATEMENT_TIMEOUT=%q, using 10s: %v", value, err)
+		return 10 * time.Second
+	}
+	return d
+}